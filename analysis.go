@@ -0,0 +1,478 @@
+package tracerygo
+
+import (
+	"math"
+	"sort"
+)
+
+// This is a summary of what a grammar can produce, assuming each rule for a name is picked uniformly as EvaluateName does
+type Analysis struct {
+	// Per name in the grammar
+	Symbols map[string]SymbolAnalysis
+	// Names that are referenced but not defined in the grammar; these are expected to come from variables bound by a caller or from a lookup, and are treated as a single opaque value
+	External []string
+}
+
+// This summarizes a single name in the grammar
+type SymbolAnalysis struct {
+	// How many different expansions the name has; this is +Inf when the name can recurse
+	Expansions float64
+	// If the name can end up expanding itself
+	Recursive bool
+	// The expected length of the output in bytes; this is +Inf when a recursive grammar is expected to never finish
+	ExpectedLength float64
+	// The expected number of times this name is expanded during one evaluation of the root; this is a count, so it can be more than 1. Only set when a root is given
+	ExpectedUses float64
+	// The chance this name is expanded at least once during one evaluation of the root; only set when a root is given
+	ReachProbability float64
+	// Per rule, in the same order as the grammar
+	Rules []RuleAnalysis
+}
+
+// This summarizes a single rule for a name
+type RuleAnalysis struct {
+	// The chance this rule is picked each time the name is expanded
+	ChoiceProbability float64
+	// The expected number of times this rule is picked during one evaluation of the root; this is a count, so it can be more than 1. Only set when a root is given
+	ExpectedUses float64
+	// The chance this rule is picked at least once during one evaluation of the root; only set when a root is given
+	ReachProbability float64
+	// How many different expansions the rule has
+	Expansions float64
+	// The expected length of the output of this rule in bytes
+	ExpectedLength float64
+}
+
+const (
+	// how many rounds of refinement are used on recursive grammars before giving up
+	analysisIterations = 1000
+	// anything bigger than this while refining is considered unbounded
+	analysisDivergence = 1e12
+)
+
+// this is the approximate number of bytes each modifier adds
+var modifierLength = map[int]float64{
+	modifierPastTenseIndex:         2,
+	modifierIndefiniteArticleIndex: 2,
+	modifierPluralizeIndex:         1,
+	modifierOrdinalIndex:           2,
+	modifierDefiniteArticleIndex:   4,
+	modifierAgreeIndex:             1,
+}
+
+// this is the shape of a rule once the text is stripped away
+type ruleProfile struct {
+	// bytes written directly by the rule
+	literal float64
+	// how many times the output of each name is included in the output of the rule
+	lengths map[string]float64
+	// every expansion of a name the rule performs, including ones inside variable declarations that are then reused
+	expansions []string
+	// how many more expansions the rule has from picking among the rules of rule set variables
+	factor float64
+	// when this is the profile of a lazy variable, each reference expands it again
+	lazy bool
+}
+
+func profileNode(variables []Variable, parts []interface{}, scope map[string]*ruleProfile) *ruleProfile {
+	p := &ruleProfile{lengths: make(map[string]float64), factor: 1}
+
+	if len(variables) != 0 {
+		local := make(map[string]*ruleProfile, len(scope)+len(variables))
+		for k, v := range scope {
+			local[k] = v
+		}
+		for _, v := range variables {
+			vp := profileVariable(v, local)
+			if v.Lazy {
+				vp.lazy = true
+				local[v.Key] = vp
+				continue
+			}
+			p.expansions = append(p.expansions, vp.expansions...)
+			if v.Rules == nil {
+				// the choices are made once, when declared, rather than on each reference
+				p.factor *= vp.factor
+				vp.factor = 1
+			}
+			local[v.Key] = vp
+		}
+		scope = local
+	}
+
+	for _, abstract := range parts {
+		switch v := abstract.(type) {
+		case string:
+			p.literal += float64(len(v))
+		case Substitution:
+			for _, sv := range v.Variables {
+				if sv.Lazy {
+					continue
+				}
+				svp := profileVariable(sv, scope)
+				p.expansions = append(p.expansions, svp.expansions...)
+				if sv.Rules == nil {
+					p.factor *= svp.factor
+				}
+			}
+			for _, m := range v.Modifiers {
+				p.literal += modifierLength[m]
+			}
+			if bound, ok := scope[v.Key]; ok {
+				if bound.lazy {
+					p.expansions = append(p.expansions, bound.expansions...)
+				}
+				p.factor *= bound.factor
+				p.literal += bound.literal
+				for k, count := range bound.lengths {
+					p.lengths[k] += count
+				}
+			} else if gen, isCall, err := parseGenerator(v.Key); isCall && err == nil {
+				p.factor *= gen.expansions()
+				p.literal += gen.expectedLength()
+			} else {
+				p.lengths[v.Key]++
+				p.expansions = append(p.expansions, v.Key)
+			}
+		case Conditional:
+			// either branch could be taken, so both are counted; this overestimates the expansions but keeps the length as the average
+			for _, branch := range []Node{v.Then, v.Else} {
+				bp := profileNode(branch.Variables, branch.Parts, scope)
+				p.expansions = append(p.expansions, bp.expansions...)
+				p.factor *= bp.factor
+				p.literal += bp.literal / 2
+				for k, count := range bp.lengths {
+					p.lengths[k] += count / 2
+				}
+			}
+		}
+	}
+	return p
+}
+
+// this profiles a variable declaration; a rule set averages its rules, and each reference to it picks one of them
+func profileVariable(v Variable, scope map[string]*ruleProfile) *ruleProfile {
+	if v.Rules == nil {
+		return profileNode(nil, v.Parts, scope)
+	}
+	p := &ruleProfile{lengths: make(map[string]float64), factor: float64(len(v.Rules))}
+	for _, r := range v.Rules {
+		rp := profileNode(nil, r, scope)
+		p.expansions = append(p.expansions, rp.expansions...)
+		p.literal += rp.literal / float64(len(v.Rules))
+		for k, count := range rp.lengths {
+			p.lengths[k] += count / float64(len(v.Rules))
+		}
+	}
+	return p
+}
+
+// This analyses every name in the grammar. If root is given, it also works out how often each name and rule is expected to be used when evaluating the root.
+// Names bound by a variable in a caller (e.g. '[hero:#name#]') are analysed in isolation, so references to them are counted as external
+func Analyze(g Grammar, root string) Analysis {
+	profiles := make(map[string][]*ruleProfile, len(g))
+	external := make(map[string]bool)
+	for name, nodes := range g {
+		for _, n := range nodes {
+			profiles[name] = append(profiles[name], profileNode(n.Variables, n.Parts, nil))
+		}
+	}
+	for _, rules := range profiles {
+		for _, p := range rules {
+			for _, name := range p.expansions {
+				if len(profiles[name]) == 0 {
+					external[name] = true
+				}
+			}
+		}
+	}
+
+	result := Analysis{Symbols: make(map[string]SymbolAnalysis, len(profiles))}
+	for name := range external {
+		result.External = append(result.External, name)
+	}
+	sort.Strings(result.External)
+
+	recursive, infinite := analyzeReach(profiles)
+	expansions := analyzeExpansions(profiles, infinite)
+	lengths := analyzeLengths(profiles)
+	var uses map[string]float64
+	var reach *reachSystem
+	if root != "" {
+		uses = analyzeUses(profiles, root)
+		reach = newReachSystem(profiles, root)
+	}
+
+	for name, rules := range profiles {
+		s := SymbolAnalysis{
+			Expansions:     expansions[name],
+			Recursive:      recursive[name],
+			ExpectedLength: lengths[name],
+			ExpectedUses:   uses[name],
+			Rules:          make([]RuleAnalysis, len(rules)),
+		}
+		if root != "" {
+			s.ReachProbability = reach.probability(name, -1)
+		}
+		for i, p := range rules {
+			s.Rules[i] = RuleAnalysis{
+				ChoiceProbability: 1 / float64(len(rules)),
+				ExpectedUses:      uses[name] / float64(len(rules)),
+				Expansions:        ruleExpansions(p, expansions),
+				ExpectedLength:    ruleLength(p, lengths),
+			}
+			if root != "" {
+				s.Rules[i].ReachProbability = reach.probability(name, i)
+			}
+		}
+		result.Symbols[name] = s
+	}
+	return result
+}
+
+// this works out which names can expand themselves, and which names can reach one that does
+func analyzeReach(profiles map[string][]*ruleProfile) (recursive, infinite map[string]bool) {
+	reach := make(map[string]map[string]bool, len(profiles))
+	var visit func(name string, seen map[string]bool)
+	visit = func(name string, seen map[string]bool) {
+		for _, p := range profiles[name] {
+			for _, next := range p.expansions {
+				if !seen[next] {
+					seen[next] = true
+					visit(next, seen)
+				}
+			}
+		}
+	}
+	recursive = make(map[string]bool)
+	for name := range profiles {
+		reach[name] = make(map[string]bool)
+		visit(name, reach[name])
+		recursive[name] = reach[name][name]
+	}
+	infinite = make(map[string]bool)
+	for name := range profiles {
+		infinite[name] = recursive[name]
+		for other := range reach[name] {
+			infinite[name] = infinite[name] || recursive[other]
+		}
+	}
+	return recursive, infinite
+}
+
+func analyzeExpansions(profiles map[string][]*ruleProfile, infinite map[string]bool) map[string]float64 {
+	counts := make(map[string]float64, len(profiles))
+	var count func(name string) float64
+	count = func(name string) float64 {
+		if len(profiles[name]) == 0 {
+			return 1
+		}
+		if c, ok := counts[name]; ok {
+			return c
+		}
+		if infinite[name] {
+			counts[name] = math.Inf(1)
+			return counts[name]
+		}
+		c := 0.0
+		for _, p := range profiles[name] {
+			rc := p.factor
+			for _, next := range p.expansions {
+				rc *= count(next)
+			}
+			c += rc
+		}
+		counts[name] = c
+		return c
+	}
+	for name := range profiles {
+		count(name)
+	}
+	return counts
+}
+
+func ruleExpansions(p *ruleProfile, expansions map[string]float64) float64 {
+	c := p.factor
+	for _, next := range p.expansions {
+		if e, ok := expansions[next]; ok {
+			c *= e
+		}
+	}
+	return c
+}
+
+func ruleLength(p *ruleProfile, lengths map[string]float64) float64 {
+	l := p.literal
+	for name, count := range p.lengths {
+		l += count * lengths[name]
+	}
+	return l
+}
+
+// this repeatedly applies a step until the values settle; values that are still growing when the iterations run out are unbounded and become +Inf
+func refine(step func(map[string]float64) map[string]float64) map[string]float64 {
+	values := make(map[string]float64)
+	moving := make(map[string]bool)
+	for i := 0; i < analysisIterations; i++ {
+		next := step(values)
+		moving = make(map[string]bool)
+		for name, v := range next {
+			if v > analysisDivergence {
+				next[name] = math.Inf(1)
+			} else if math.Abs(v-values[name]) > 1e-9 {
+				moving[name] = true
+			}
+		}
+		values = next
+		if len(moving) == 0 {
+			return values
+		}
+	}
+	for name := range moving {
+		values[name] = math.Inf(1)
+	}
+	return values
+}
+
+// this works out the expected lengths; each is the average of its rules
+func analyzeLengths(profiles map[string][]*ruleProfile) map[string]float64 {
+	return refine(func(lengths map[string]float64) map[string]float64 {
+		next := make(map[string]float64, len(profiles))
+		for name, rules := range profiles {
+			total := 0.0
+			for _, p := range rules {
+				total += ruleLength(p, lengths)
+			}
+			next[name] = total / float64(len(rules))
+		}
+		return next
+	})
+}
+
+// this works out how often each name is expanded starting from the root; each rule passes on its share of the uses to everything it expands
+func analyzeUses(profiles map[string][]*ruleProfile, root string) map[string]float64 {
+	return refine(func(uses map[string]float64) map[string]float64 {
+		next := map[string]float64{root: 1}
+		for name, rules := range profiles {
+			if uses[name] == 0 {
+				continue
+			}
+			for _, p := range rules {
+				for _, other := range p.expansions {
+					next[other] += uses[name] / float64(len(rules))
+				}
+			}
+		}
+		return next
+	})
+}
+
+// this is the grammar reduced to what the reach probabilities need: the names are numbered, and each rule is the list of names it expands.
+// It's built once so that working out the chance of each name and rule doesn't go back through the profiles
+type reachSystem struct {
+	index map[string]int
+	rules [][][]int
+	// for each name, the names that can expand it directly
+	parents [][]int
+	// the names that evaluating the root can expand, in the order they were found
+	reachable []int
+	root      int
+}
+
+func newReachSystem(profiles map[string][]*ruleProfile, root string) *reachSystem {
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	s := &reachSystem{index: make(map[string]int, len(names)), rules: make([][][]int, len(names)), parents: make([][]int, len(names)), root: -1}
+	for i, name := range names {
+		s.index[name] = i
+	}
+	for i, name := range names {
+		s.rules[i] = make([][]int, len(profiles[name]))
+		for j, p := range profiles[name] {
+			for _, other := range p.expansions {
+				// names from outside the grammar have no rules to match, so they always miss and can be left out
+				if k, ok := s.index[other]; ok {
+					s.rules[i][j] = append(s.rules[i][j], k)
+					s.parents[k] = append(s.parents[k], i)
+				}
+			}
+		}
+	}
+	if r, ok := s.index[root]; ok {
+		s.root = r
+		seen := map[int]bool{r: true}
+		s.reachable = []int{r}
+		for n := 0; n < len(s.reachable); n++ {
+			for _, rule := range s.rules[s.reachable[n]] {
+				for _, k := range rule {
+					if !seen[k] {
+						seen[k] = true
+						s.reachable = append(s.reachable, k)
+					}
+				}
+			}
+		}
+	}
+	return s
+}
+
+// this works out the chance that evaluating the root picks the rule of a name at least once, or any of its rules when the rule is -1. Each expansion picks
+// its rule independently, so the chance a name misses is the average over its rules of the chance that everything the rule expands misses too; this starts
+// from everything missing and is refined until it settles, which also covers recursive grammars. Only the names that can lead to the target can miss it,
+// so everything else is left out, and each name is updated in place so later names in a round already use the refined values
+func (s *reachSystem) probability(name string, rule int) float64 {
+	target, ok := s.index[name]
+	if !ok || s.root < 0 {
+		return 0
+	}
+	leads := make([]bool, len(s.rules))
+	leads[target] = true
+	for queue := []int{target}; len(queue) > 0; queue = queue[1:] {
+		for _, parent := range s.parents[queue[0]] {
+			if !leads[parent] {
+				leads[parent] = true
+				queue = append(queue, parent)
+			}
+		}
+	}
+	if !leads[s.root] {
+		return 0
+	}
+	var relevant []int
+	for _, n := range s.reachable {
+		if leads[n] {
+			relevant = append(relevant, n)
+		}
+	}
+
+	miss := make([]float64, len(s.rules))
+	for i := range miss {
+		miss[i] = 1
+	}
+	for i := 0; i < analysisIterations; i++ {
+		moving := false
+		for _, n := range relevant {
+			total := 0.0
+			for j, expansions := range s.rules[n] {
+				if n == target && (rule < 0 || rule == j) {
+					continue
+				}
+				m := 1.0
+				for _, other := range expansions {
+					m *= miss[other]
+				}
+				total += m
+			}
+			next := total / float64(len(s.rules[n]))
+			moving = moving || math.Abs(next-miss[n]) > 1e-12
+			miss[n] = next
+		}
+		if !moving {
+			break
+		}
+	}
+	return 1 - miss[s.root]
+}
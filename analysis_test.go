@@ -0,0 +1,77 @@
+package tracerygo
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnalyze(t *testing.T) {
+	t.Run("finite grammar", func(t *testing.T) {
+		g, err := Parse(RawGrammar{
+			"origin": []string{"#a# #b.s#", "[x:#a#]#x##x#"},
+			"a":      []string{"red", "blue"},
+			"b":      []string{"fox", "owl", "#hero#"},
+		})
+		if !assert.Nil(t, err) {
+			return
+		}
+		a := Analyze(g, "origin")
+		assert.Equal(t, []string{"hero"}, a.External)
+
+		origin := a.Symbols["origin"]
+		assert.Equal(t, 6.0+2.0, origin.Expansions)
+		assert.False(t, origin.Recursive)
+		assert.Equal(t, 1.0, origin.ExpectedUses)
+		assert.Equal(t, 6.0, origin.Rules[0].Expansions)
+		assert.Equal(t, 2.0, origin.Rules[1].Expansions)
+		assert.Equal(t, 0.5, origin.Rules[1].ChoiceProbability)
+		// "#a# #b.s#" is 3.5 + 1 + 2 + 1, "#x##x#" is 3.5 * 2
+		assert.InDelta(t, 7.5, origin.Rules[0].ExpectedLength, 1e-9)
+		assert.InDelta(t, 7.0, origin.Rules[1].ExpectedLength, 1e-9)
+		assert.InDelta(t, 7.25, origin.ExpectedLength, 1e-9)
+
+		assert.InDelta(t, 1.0, a.Symbols["a"].ExpectedUses, 1e-9)
+		assert.InDelta(t, 0.5, a.Symbols["b"].ExpectedUses, 1e-9)
+		assert.InDelta(t, 0.5, a.Symbols["a"].Rules[1].ExpectedUses, 1e-9)
+
+		// '#a#' is expanded once either way, so each of its rules is reached half the time; 'b' only by the first rule of origin
+		assert.InDelta(t, 1.0, origin.ReachProbability, 1e-9)
+		assert.InDelta(t, 1.0, a.Symbols["a"].ReachProbability, 1e-9)
+		assert.InDelta(t, 0.5, a.Symbols["a"].Rules[1].ReachProbability, 1e-9)
+		assert.InDelta(t, 0.5, a.Symbols["b"].ReachProbability, 1e-9)
+		assert.InDelta(t, 0.5/3, a.Symbols["b"].Rules[2].ReachProbability, 1e-9)
+	})
+	t.Run("recursive grammar", func(t *testing.T) {
+		g, err := Parse(RawGrammar{
+			"origin": []string{"#list#"},
+			"list":   []string{"x,#list#", "x"},
+		})
+		if !assert.Nil(t, err) {
+			return
+		}
+		a := Analyze(g, "origin")
+		assert.True(t, a.Symbols["list"].Recursive)
+		assert.False(t, a.Symbols["origin"].Recursive)
+		assert.True(t, math.IsInf(a.Symbols["origin"].Expansions, 1))
+		// each level adds 2 bytes half the time; L = 0.5 * (2 + L) + 0.5 * 1
+		assert.InDelta(t, 3.0, a.Symbols["list"].ExpectedLength, 1e-6)
+		assert.InDelta(t, 2.0, a.Symbols["list"].ExpectedUses, 1e-6)
+		// the last item is always reached, and another comes first half the time, so the expected uses are more than 1 but the chances aren't
+		assert.InDelta(t, 1.0, a.Symbols["list"].Rules[1].ReachProbability, 1e-6)
+		assert.InDelta(t, 0.5, a.Symbols["list"].Rules[0].ReachProbability, 1e-6)
+		assert.InDelta(t, 1.0, a.Symbols["list"].Rules[0].ExpectedUses, 1e-6)
+	})
+	t.Run("runaway grammar", func(t *testing.T) {
+		g, err := Parse(RawGrammar{
+			"origin": []string{"#origin##origin#", "x"},
+		})
+		if !assert.Nil(t, err) {
+			return
+		}
+		a := Analyze(g, "")
+		assert.True(t, math.IsInf(a.Symbols["origin"].ExpectedLength, 1))
+		assert.Equal(t, 0.0, a.Symbols["origin"].ExpectedUses)
+	})
+}
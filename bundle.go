@@ -0,0 +1,129 @@
+package tracerygo
+
+import (
+	"sort"
+	"strings"
+)
+
+// This holds translations of the same grammar, keyed by language tag (e.g. 'fr-CA'). Symbols missing from a translation fall back to less specific tags, then the default
+type Bundle struct {
+	// The tag of the grammar everything falls back to, and that translations are made from
+	Default string
+	// The grammar for each tag
+	Grammars map[string]RawGrammar
+}
+
+// This creates an empty bundle that falls back to the given tag
+func NewBundle(defaultTag string) *Bundle {
+	return &Bundle{Default: canonicalTag(defaultTag), Grammars: make(map[string]RawGrammar)}
+}
+
+// This adds the grammar for a tag, replacing any already there
+func (b *Bundle) Add(tag string, g RawGrammar) {
+	if b.Grammars == nil {
+		b.Grammars = make(map[string]RawGrammar)
+	}
+	b.Grammars[canonicalTag(tag)] = g
+}
+
+// This lists the tags a tag falls back to, most specific first, e.g. 'fr-CA', 'fr', 'en'
+func (b *Bundle) Fallbacks(tag string) []string {
+	var tags []string
+	seen := make(map[string]bool)
+	add := func(t string) {
+		if t != "" && !seen[t] {
+			seen[t] = true
+			tags = append(tags, t)
+		}
+	}
+	subtags := strings.Split(canonicalTag(tag), "-")
+	for i := len(subtags); i > 0; i-- {
+		add(strings.Join(subtags[:i], "-"))
+	}
+	add(b.Default)
+	return tags
+}
+
+// This merges the grammars for a tag and its fallbacks, taking each symbol from the most specific grammar that has it
+func (b *Bundle) Resolve(tag string) RawGrammar {
+	merged := make(RawGrammar)
+	fallbacks := b.Fallbacks(tag)
+	for i := len(fallbacks) - 1; i >= 0; i-- {
+		for name, rules := range b.Grammars[fallbacks[i]] {
+			merged[name] = rules
+		}
+	}
+	return merged
+}
+
+// This parses the merged grammar for a tag
+func (b *Bundle) Parse(tag string) (Grammar, error) {
+	return Parse(b.Resolve(tag))
+}
+
+// This evaluates a name in the merged grammar for a tag, with the modifiers following the rules of the most specific language the bundle has a grammar for
+func (b *Bundle) Evaluate(tag string, name string, seed int64) (string, error) {
+	g, err := b.Parse(tag)
+	if err != nil {
+		return "", err
+	}
+	modifiers := []EvaluationModifier{WithGrammar(g), seedRandom(seed)}
+	// the language is the one of the most specific grammar there is, since that's what most of the text is in
+	for _, t := range b.Fallbacks(tag) {
+		if _, ok := b.Grammars[t]; ok {
+			if l, ok := LocaleFor(t); ok {
+				modifiers = append(modifiers, WithLocale(l))
+			}
+			break
+		}
+	}
+	var sb strings.Builder
+	e := NewEvaluation(&sb, modifiers...)
+	n, err := e.EvaluateName(name)
+	if err != nil {
+		return "", err
+	}
+	if err = e.Evaluate(n); err == nil {
+		err = e.Flush()
+	}
+	return sb.String(), err
+}
+
+// This lists the symbols of the default grammar that a tag only gets from the default, because neither it nor any of its other fallbacks translate them
+func (b *Bundle) Missing(tag string) []string {
+	var missing []string
+	fallbacks := b.Fallbacks(tag)
+	if fallbacks[0] == b.Default {
+		return nil
+	}
+	for name := range b.Grammars[b.Default] {
+		found := false
+		for _, t := range fallbacks {
+			if _, ok := b.Grammars[t][name]; ok && t != b.Default {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, name)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// this writes a tag consistently, e.g. 'fr_ca' becomes 'fr-CA'
+func canonicalTag(tag string) string {
+	subtags := strings.Split(strings.Replace(tag, "_", "-", -1), "-")
+	for i, s := range subtags {
+		switch {
+		case i == 0:
+			subtags[i] = strings.ToLower(s)
+		case len(s) == 2:
+			subtags[i] = strings.ToUpper(s)
+		case len(s) == 4:
+			subtags[i] = strings.ToUpper(s[:1]) + strings.ToLower(s[1:])
+		}
+	}
+	return strings.Join(subtags, "-")
+}
@@ -0,0 +1,58 @@
+package tracerygo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testBundle() *Bundle {
+	b := NewBundle("en")
+	b.Add("en", RawGrammar{
+		"origin":   []string{"#greeting#, #animal.a#!"},
+		"greeting": []string{"hello"},
+		"animal":   []string{"cat"},
+		"farewell": []string{"goodbye"},
+	})
+	b.Add("fr", RawGrammar{
+		"origin":   []string{"#greeting#, #animal.the#!"},
+		"greeting": []string{"bonjour"},
+		"animal":   []string{"arbre"},
+	})
+	b.Add("fr_ca", RawGrammar{
+		"greeting": []string{"allô"},
+	})
+	return b
+}
+
+func TestBundle(t *testing.T) {
+	b := testBundle()
+
+	t.Run("fallbacks", func(t *testing.T) {
+		assert.Equal(t, []string{"fr-CA", "fr", "en"}, b.Fallbacks("fr-ca"))
+		assert.Equal(t, []string{"zh-Hant-TW", "zh-Hant", "zh", "en"}, b.Fallbacks("zh_hant_tw"))
+		assert.Equal(t, []string{"en"}, b.Fallbacks("EN"))
+	})
+	t.Run("resolve", func(t *testing.T) {
+		assert.Equal(t, RawGrammar{
+			"origin":   []string{"#greeting#, #animal.the#!"},
+			"greeting": []string{"allô"},
+			"animal":   []string{"arbre"},
+			"farewell": []string{"goodbye"},
+		}, b.Resolve("fr-CA"))
+		assert.Equal(t, b.Grammars["en"], b.Resolve("de"))
+	})
+	t.Run("evaluate with the locale's modifiers", func(t *testing.T) {
+		for tag, expected := range map[string]string{"en": "hello, a cat!", "fr": "bonjour, l'arbre!", "fr-CA": "allô, l'arbre!", "de": "hello, a cat!"} {
+			result, err := b.Evaluate(tag, "origin", 0)
+			assert.Nil(t, err, tag)
+			assert.Equal(t, expected, result, tag)
+		}
+	})
+	t.Run("missing", func(t *testing.T) {
+		assert.Equal(t, []string{"farewell"}, b.Missing("fr"))
+		assert.Equal(t, []string{"farewell"}, b.Missing("fr-CA"))
+		assert.Equal(t, []string{"animal", "farewell", "greeting", "origin"}, b.Missing("de"))
+		assert.Nil(t, b.Missing("en"))
+	})
+}
@@ -0,0 +1,147 @@
+package tracerygo
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// This exposes structured data, like a struct or a map[string]interface{}, to the grammar with paths like '#$user.firstName#'.
+// Struct fields match by name, json tag or case-insensitively; list items match by index, and a whole list is a set of rules to choose from
+func WithData(v interface{}) EvaluationModifier {
+	return func(e *Evaluation) {
+		e.data = v
+	}
+}
+
+// this follows a data path (e.g. '$user.firstName') and returns the value found as rules; the values are written as is, rather than expanded
+func (e *Evaluation) dataNodes(path string) ([]Node, error) {
+	if e.data == nil {
+		return nil, ErrorPathNotFound{path, "no data was given to the evaluation"}
+	}
+	value := reflect.ValueOf(e.data)
+	if path != "$" {
+		for _, segment := range strings.Split(path[1:], ".") {
+			next, reason := dataChild(indirect(value), segment)
+			if reason != "" {
+				return nil, ErrorPathNotFound{path, reason}
+			}
+			value = next
+		}
+	}
+
+	value = indirect(value)
+	if !value.IsValid() {
+		return nil, ErrorPathNotFound{path, "the value is empty"}
+	}
+	if value.Kind() != reflect.Slice && value.Kind() != reflect.Array {
+		text, ok := dataText(value)
+		if !ok {
+			return nil, ErrorPathNotFound{path, "the value isn't text, a number or a list"}
+		}
+		return []Node{dataNode(text)}, nil
+	}
+	if value.Len() == 0 {
+		return nil, ErrorPathNotFound{path, "the list is empty"}
+	}
+	nodes := make([]Node, value.Len())
+	for i := range nodes {
+		text, ok := dataText(indirect(value.Index(i)))
+		if !ok {
+			return nil, ErrorPathNotFound{path, fmt.Sprintf("item %d isn't text or a number", i)}
+		}
+		nodes[i] = dataNode(text)
+	}
+	return nodes, nil
+}
+
+// this wraps a data value as a rule, leaving an empty value with no parts at all
+func dataNode(text string) Node {
+	if text == "" {
+		return Node{}
+	}
+	return Node{Parts: []interface{}{text}}
+}
+
+// this looks through pointers and interfaces to the value underneath
+func indirect(v reflect.Value) reflect.Value {
+	for v.IsValid() && (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) {
+		v = v.Elem()
+	}
+	return v
+}
+
+// this finds a field, key or index of a value; if it can't, it says why
+func dataChild(v reflect.Value, segment string) (reflect.Value, string) {
+	switch v.Kind() {
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return v, fmt.Sprintf("'%s' can't be looked up in a map without text keys", segment)
+		}
+		child := v.MapIndex(reflect.ValueOf(segment).Convert(v.Type().Key()))
+		if !child.IsValid() {
+			return v, fmt.Sprintf("there's no key '%s'", segment)
+		}
+		return child, ""
+	case reflect.Struct:
+		if child, ok := structField(v, segment); ok {
+			return child, ""
+		}
+		return v, fmt.Sprintf("there's no field '%s'", segment)
+	case reflect.Slice, reflect.Array:
+		i, err := strconv.Atoi(segment)
+		if err != nil {
+			return v, fmt.Sprintf("'%s' isn't an index into the list", segment)
+		}
+		if i < 0 || i >= v.Len() {
+			return v, fmt.Sprintf("%d is outside the list of %d", i, v.Len())
+		}
+		return v.Index(i), ""
+	case reflect.Invalid:
+		return v, fmt.Sprintf("'%s' can't be looked up in an empty value", segment)
+	}
+	return v, fmt.Sprintf("'%s' can't be looked up in a %s", segment, v.Kind())
+}
+
+// this finds an exported struct field by its name, its json tag, or its name ignoring case, in that order
+func structField(v reflect.Value, segment string) (reflect.Value, bool) {
+	t := v.Type()
+	if f, ok := t.FieldByName(segment); ok && f.PkgPath == "" {
+		return v.FieldByIndex(f.Index), true
+	}
+	folded := -1
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		if tag := strings.Split(f.Tag.Get("json"), ",")[0]; tag == segment {
+			return v.Field(i), true
+		}
+		if folded < 0 && strings.EqualFold(f.Name, segment) {
+			folded = i
+		}
+	}
+	if folded >= 0 {
+		return v.Field(folded), true
+	}
+	return v, false
+}
+
+// this writes out a value that can be written out: text, numbers, booleans, and anything with a String method
+func dataText(v reflect.Value) (string, bool) {
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), true
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Float32, reflect.Float64:
+		return fmt.Sprint(v.Interface()), true
+	case reflect.Invalid:
+		return "", false
+	}
+	if s, ok := v.Interface().(fmt.Stringer); ok {
+		return s.String(), true
+	}
+	return "", false
+}
@@ -0,0 +1,99 @@
+package tracerygo
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testPet struct {
+	Kind string
+	Name string `json:"nickname"`
+}
+
+type testUser struct {
+	FirstName string
+	Age       int
+	Pets      []testPet
+	Tags      []string
+	Joined    time.Time
+	Manager   *testUser
+	secret    string
+}
+
+func TestData(t *testing.T) {
+	user := testUser{
+		FirstName: "ada",
+		Age:       36,
+		Pets:      []testPet{{"cat", "Whiskers"}, {"dog", "Rex"}},
+		Tags:      []string{"curious", "precise"},
+		Joined:    time.Date(1843, 7, 1, 0, 0, 0, 0, time.UTC),
+		secret:    "hidden",
+	}
+	data := map[string]interface{}{
+		"user":  &user,
+		"items": []interface{}{"lamp", 3},
+		"order": map[string]string{"s": "small", "a": "first"},
+		"blank": "",
+	}
+	evaluate := func(rule string) (string, error) {
+		return evaluateRule(rule, WithData(data))
+	}
+
+	t.Run("paths", func(t *testing.T) {
+		for rule, expected := range map[string]string{
+			"#$user.firstName.capitalize# is #$user.age#":          "Ada is 36",
+			"#$user.FirstName# has #$user.pets.1.nickname.a#":      "ada has a Rex",
+			"#$user.pets.0.kind.s#":                                "cats",
+			"#$items.1# #$items.0.s#":                              "3 lamps",
+			"#$user.joined#":                                       user.Joined.String(),
+			"[name:#$user.firstName#]#name.capitalize# #name.s#":   "Ada adas",
+			"#$order.\\s.capitalize# #$order.\\a.a#":               "Small a first",
+			"[if $user.firstName==ada:yes|no][if $user.age!=36:!]": "yes",
+		} {
+			result, err := evaluate(rule)
+			assert.Nil(t, err, rule)
+			assert.Equal(t, expected, result, rule)
+		}
+	})
+	t.Run("empty values", func(t *testing.T) {
+		for rule, expected := range map[string]string{
+			"#$blank.capitalize#":         "",
+			"#$blank.a#":                  "",
+			"[x:#$blank#]#x.capitalize#!": "!",
+			"[if $blank==:empty|not]":     "empty",
+		} {
+			result, err := evaluate(rule)
+			assert.Nil(t, err, rule)
+			assert.Equal(t, expected, result, rule)
+		}
+	})
+	t.Run("lists are choices", func(t *testing.T) {
+		seen := make(map[string]bool)
+		for seed := int64(0); seed < 20; seed++ {
+			var sb strings.Builder
+			e := NewEvaluation(&sb, WithData(data), WithStableRandom(seed))
+			assert.Nil(t, e.Evaluate(Node{Parts: []interface{}{Substitution{Key: "$user.tags"}}}))
+			seen[sb.String()] = true
+		}
+		assert.Equal(t, map[string]bool{"curious": true, "precise": true}, seen)
+	})
+	t.Run("missing paths", func(t *testing.T) {
+		for rule, expected := range map[string]error{
+			"#$user.nickname#":       ErrorPathNotFound{"$user.nickname", "there's no field 'nickname'"},
+			"#$user.secret#":         ErrorPathNotFound{"$user.secret", "there's no field 'secret'"},
+			"#$user.pets.2.kind#":    ErrorPathNotFound{"$user.pets.2.kind", "2 is outside the list of 2"},
+			"#$user.manager.age#":    ErrorPathNotFound{"$user.manager.age", "'age' can't be looked up in an empty value"},
+			"#$team#":                ErrorPathNotFound{"$team", "there's no key 'team'"},
+			"#$user.pets#":           ErrorPathNotFound{"$user.pets", "item 0 isn't text or a number"},
+			"#$user.firstName.size#": ErrorPathNotFound{"$user.firstName.size", "'size' can't be looked up in a string"},
+		} {
+			_, err := evaluate(rule)
+			assert.Equal(t, expected, err, rule)
+		}
+		err := NewEvaluation(&strings.Builder{}).Evaluate(Node{Parts: []interface{}{Substitution{Key: "$user"}}})
+		assert.Equal(t, ErrorPathNotFound{"$user", "no data was given to the evaluation"}, err)
+	})
+}
@@ -0,0 +1,68 @@
+package tracerygo
+
+import (
+	"errors"
+)
+
+// this is returned by the matcher as soon as the output stops lining up with the target
+var errMismatch = errors.New("output does not match")
+
+// This is a single decision made while evaluating; the rule that was picked for a name
+type Choice struct {
+	Name string
+	Rule int
+}
+
+// This is every decision, in the order they were made, that leads to an output
+type Derivation []Choice
+
+// this is a writer that checks the stream against a target as it is written, so a walk can stop as soon as it diverges
+type matcher struct {
+	target     string
+	position   int
+	mismatched bool
+}
+
+func (m *matcher) Write(b []byte) (int, error) {
+	if m.mismatched || len(b) > len(m.target)-m.position || m.target[m.position:m.position+len(b)] != string(b) {
+		m.mismatched = true
+		return 0, errMismatch
+	}
+	m.position += len(b)
+	return len(b), nil
+}
+
+// This finds every derivation of a name that produces exactly the output, or returns ErrorNotInLanguage if there are none.
+// The depth caps how many choices a single derivation can take, which recursive grammars need to terminate; 0 is unlimited.
+// If a derivation that still lined up with the output was cut off by the depth, the derivations found are returned with an ErrorTruncated, since deeper ones may have been missed
+func (g Grammar) Derive(name string, output string, maxDepth int) ([]Derivation, error) {
+	var derivations []Derivation
+	truncated := false
+	o := &odometer{maxDepth: maxDepth}
+	for {
+		m := &matcher{target: output}
+		pruned, err := o.run(g, symbolNode(name), m)
+		if err != nil && !m.mismatched {
+			return derivations, err
+		}
+		// a run cut off before it went wrong might have matched had it been allowed to go deeper
+		truncated = truncated || (pruned && !m.mismatched)
+		if !pruned && !m.mismatched && m.position == len(output) {
+			d := make(Derivation, o.position)
+			for i, p := range o.points[:o.position] {
+				d[i] = Choice{p.name, p.choice}
+			}
+			derivations = append(derivations, d)
+		}
+		if !o.advance() {
+			break
+		}
+	}
+	if truncated {
+		return derivations, ErrorTruncated{name, len(derivations)}
+	}
+	if len(derivations) == 0 {
+		return nil, ErrorNotInLanguage{name, output}
+	}
+	return derivations, nil
+}
@@ -0,0 +1,66 @@
+package tracerygo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDerive(t *testing.T) {
+	g, err := Parse(RawGrammar{
+		"origin":   []string{"#greeting.capitalize# #name#", "[n:#name#]#n# and #n#"},
+		"greeting": []string{"hello", "hi"},
+		"name":     []string{"world", "there", "hi"},
+		"list":     []string{"x", "x,#list#"},
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	t.Run("single derivation", func(t *testing.T) {
+		d, err := g.Derive("origin", "Hi there", 0)
+		assert.Nil(t, err)
+		assert.Equal(t, []Derivation{{{"origin", 0}, {"greeting", 1}, {"name", 1}}}, d)
+	})
+	t.Run("through a variable", func(t *testing.T) {
+		d, err := g.Derive("origin", "world and world", 0)
+		assert.Nil(t, err)
+		assert.Equal(t, []Derivation{{{"origin", 1}, {"name", 0}}}, d)
+	})
+	t.Run("recursive", func(t *testing.T) {
+		d, err := g.Derive("list", "x,x,x", 10)
+		assert.Nil(t, err)
+		assert.Equal(t, []Derivation{{{"list", 1}, {"list", 1}, {"list", 0}}}, d)
+	})
+	t.Run("cut off by the depth", func(t *testing.T) {
+		d, err := g.Derive("list", "x,x,x,x,x", 2)
+		assert.Equal(t, ErrorTruncated{"list", 0}, err)
+		assert.Empty(t, d)
+		// once the depth is enough, runs that go deeper stop matching before they're cut off
+		d, err = g.Derive("list", "x,x,x", 3)
+		assert.Nil(t, err)
+		assert.Len(t, d, 1)
+	})
+	t.Run("not in the language", func(t *testing.T) {
+		_, err := g.Derive("origin", "hello world", 0)
+		assert.Equal(t, ErrorNotInLanguage{"origin", "hello world"}, err)
+		_, err = g.Derive("origin", "Hello world!", 0)
+		assert.Equal(t, ErrorNotInLanguage{"origin", "Hello world!"}, err)
+	})
+	t.Run("ambiguous", func(t *testing.T) {
+		g, err := Parse(RawGrammar{
+			"origin": []string{"#a##b#"},
+			"a":      []string{"x", "xy"},
+			"b":      []string{"yz", "z"},
+		})
+		if !assert.Nil(t, err) {
+			return
+		}
+		d, err := g.Derive("origin", "xyz", 0)
+		assert.Nil(t, err)
+		assert.Equal(t, []Derivation{
+			{{"a", 0}, {"b", 0}},
+			{{"a", 1}, {"b", 1}},
+		}, d)
+	})
+}
@@ -0,0 +1,33 @@
+package tracerygo
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"strings"
+)
+
+// This gives every expansion its own random stream, derived from the seed and the path to that expansion (e.g. '/line#0/substance#2').
+// Editing the rules of one name then only changes the parts of the output that expand it, rather than every choice made after it
+func WithDerivedSeeds(seed int64) EvaluationModifier {
+	return func(e *Evaluation) {
+		e.derived = true
+		e.seed = seed
+	}
+}
+
+// This evaluates like Evaluate, but with a random stream derived per expansion; see WithDerivedSeeds
+func (g Grammar) EvaluateDerived(name string, index int, seed int64) (string, error) {
+	var sb strings.Builder
+	err := g.streamingEvaluate(&sb, name, index, WithDerivedSeeds(seed))
+	return sb.String(), err
+}
+
+// this builds the random stream for a single expansion; it uses SplitMix so that derived outputs are stable across Go releases too
+func derivedRandom(seed int64, path string) Random {
+	h := fnv.New64a()
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], uint64(seed))
+	h.Write(b[:])
+	h.Write([]byte(path))
+	return NewSplitMix(int64(h.Sum64()))
+}
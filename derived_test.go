@@ -0,0 +1,53 @@
+package tracerygo
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDerivedSeeds(t *testing.T) {
+	rawg := RawGrammar{
+		"origin":    []string{"#mood#|#substance#|#substance#"},
+		"mood":      []string{"sad", "glad", "mad"},
+		"substance": []string{"mist", "fog", "glass", "silver", "rain", "dew"},
+	}
+	g, err := Parse(rawg)
+	if !assert.Nil(t, err) {
+		return
+	}
+	edited, err := Parse(RawGrammar{
+		"origin":    rawg["origin"],
+		"mood":      []string{"sad", "glad", "mad", "bad", "rad", "fad", "cad"},
+		"substance": rawg["substance"],
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	differentMood := 0
+	differentSubstances := 0
+	for seed := int64(0); seed < 50; seed++ {
+		before, err := g.EvaluateDerived("origin", 0, seed)
+		assert.Nil(t, err)
+		again, _ := g.EvaluateDerived("origin", 0, seed)
+		assert.Equal(t, before, again)
+
+		after, err := edited.EvaluateDerived("origin", 0, seed)
+		assert.Nil(t, err)
+
+		b := strings.Split(before, "|")
+		a := strings.Split(after, "|")
+		assert.Equal(t, b[1:], a[1:])
+		if a[0] != b[0] {
+			differentMood++
+		}
+		if b[1] != b[2] {
+			differentSubstances++
+		}
+	}
+	// the edit should change some moods, and each occurrence of substance should have its own stream
+	assert.NotZero(t, differentMood)
+	assert.NotZero(t, differentSubstances)
+}
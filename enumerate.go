@@ -0,0 +1,142 @@
+package tracerygo
+
+import (
+	"errors"
+	"io"
+	"strings"
+)
+
+// this is returned from a choice when the derivation has gone deeper than allowed; it prunes that branch of the walk
+var errDepthExceeded = errors.New("maximum derivation depth exceeded")
+
+// this is a single decision made while evaluating; which of the n rules for a name was used
+type choicePoint struct {
+	name   string
+	choice int
+	n      int
+}
+
+// this walks every sequence of choices depth-first, like an odometer: each run replays the recorded prefix and then takes the first rule of anything new.
+// after the run, the last choice that can still be advanced is bumped and everything after it is forgotten
+type odometer struct {
+	points   []choicePoint
+	position int
+	maxDepth int
+}
+
+func (o *odometer) choose(name string, n int) (int, error) {
+	if o.position < len(o.points) {
+		p := o.points[o.position]
+		o.position++
+		return p.choice, nil
+	}
+	if o.maxDepth > 0 && len(o.points) >= o.maxDepth {
+		return 0, errDepthExceeded
+	}
+	o.points = append(o.points, choicePoint{name, 0, n})
+	o.position++
+	return 0, nil
+}
+
+// this moves on to the next sequence of choices, returning false when every sequence has been visited
+func (o *odometer) advance() bool {
+	o.points = o.points[:o.position]
+	o.position = 0
+	for len(o.points) > 0 {
+		last := &o.points[len(o.points)-1]
+		if last.choice+1 < last.n {
+			last.choice++
+			return true
+		}
+		o.points = o.points[:len(o.points)-1]
+	}
+	return false
+}
+
+// this evaluates a single run of the odometer over a node; pruned reports if the run was abandoned for going too deep
+func (o *odometer) run(g Grammar, n Node, out io.Writer) (pruned bool, err error) {
+	e := NewEvaluation(out, WithGrammar(g))
+	e.choose = o.choose
+	err = e.Evaluate(n)
+	if errors.Is(err, errDepthExceeded) {
+		return true, nil
+	} else if err != nil {
+		return false, err
+	}
+	return false, e.Flush()
+}
+
+// this builds a node which, when evaluated, picks any of the rules of a name
+func symbolNode(name string) Node {
+	return Node{Parts: []interface{}{Substitution{Key: name}}}
+}
+
+// This walks every possible expansion of a name depth-first, trying each rule choice in order.
+// It follows the same pattern as bufio.Scanner: call Next until it returns false, then check Err
+type Enumerator struct {
+	grammar  Grammar
+	name     string
+	odometer *odometer
+	maxCount int
+
+	count     int
+	value     string
+	err       error
+	done      bool
+	truncated bool
+}
+
+// This creates an enumerator over every expansion of a name; the count caps how many expansions are produced and the depth caps how many choices a single expansion can take.
+// Either cap can be 0 for no limit, but grammars that recurse need a depth to terminate
+func (g Grammar) Enumerate(name string, maxCount, maxDepth int) *Enumerator {
+	return &Enumerator{
+		grammar:  g,
+		name:     name,
+		odometer: &odometer{maxDepth: maxDepth},
+		maxCount: maxCount,
+	}
+}
+
+// This advances to the next expansion, returning false when there are no more or an error occurs
+func (it *Enumerator) Next() bool {
+	if it.maxCount > 0 && it.count >= it.maxCount {
+		if !it.done {
+			it.done = true
+			it.truncated = true
+		}
+		return false
+	}
+	for !it.done {
+		var sb strings.Builder
+		pruned, err := it.odometer.run(it.grammar, symbolNode(it.name), &sb)
+		if err != nil {
+			it.err = err
+			it.done = true
+			return false
+		}
+		it.done = !it.odometer.advance()
+		if pruned {
+			it.truncated = true
+			continue
+		}
+		it.value = sb.String()
+		it.count++
+		return true
+	}
+	return false
+}
+
+// This is the expansion found by the last call to Next; different choices can produce the same text, so values may repeat
+func (it *Enumerator) Value() string {
+	return it.value
+}
+
+// This is the error that stopped the enumeration, if any
+func (it *Enumerator) Err() error {
+	return it.err
+}
+
+// This reports whether expansions were skipped because they hit the count or depth cap
+func (it *Enumerator) Truncated() bool {
+	return it.truncated
+}
@@ -0,0 +1,76 @@
+package tracerygo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func collect(it *Enumerator) []string {
+	var values []string
+	for it.Next() {
+		values = append(values, it.Value())
+	}
+	return values
+}
+
+func TestEnumerate(t *testing.T) {
+	t.Run("every rule choice", func(t *testing.T) {
+		g, err := Parse(RawGrammar{
+			"origin": []string{"#a.capitalize# #b.s#", "#a.a#"},
+			"a":      []string{"owl", "cat"},
+			"b":      []string{"dog", "eel"},
+		})
+		if !assert.Nil(t, err) {
+			return
+		}
+		it := g.Enumerate("origin", 0, 0)
+		assert.Equal(t, []string{"Owl dogs", "Owl eels", "Cat dogs", "Cat eels", "an owl", "a cat"}, collect(it))
+		assert.Nil(t, it.Err())
+		assert.False(t, it.Truncated())
+	})
+	t.Run("variables are chosen once", func(t *testing.T) {
+		g, err := Parse(RawGrammar{
+			"origin": []string{"[x:#a#]#x#-#x#"},
+			"a":      []string{"1", "2", "3"},
+		})
+		if !assert.Nil(t, err) {
+			return
+		}
+		assert.Equal(t, []string{"1-1", "2-2", "3-3"}, collect(g.Enumerate("origin", 0, 0)))
+	})
+	t.Run("count cap", func(t *testing.T) {
+		g, err := Parse(RawGrammar{
+			"origin": []string{"#a##a#"},
+			"a":      []string{"1", "2", "3"},
+		})
+		if !assert.Nil(t, err) {
+			return
+		}
+		it := g.Enumerate("origin", 4, 0)
+		assert.Equal(t, []string{"11", "12", "13", "21"}, collect(it))
+		assert.True(t, it.Truncated())
+	})
+	t.Run("depth cap", func(t *testing.T) {
+		g, err := Parse(RawGrammar{
+			"origin": []string{"(#origin#)", "x"},
+		})
+		if !assert.Nil(t, err) {
+			return
+		}
+		it := g.Enumerate("origin", 0, 3)
+		assert.Equal(t, []string{"((x))", "(x)", "x"}, collect(it))
+		assert.True(t, it.Truncated())
+	})
+	t.Run("errors stop the walk", func(t *testing.T) {
+		g, err := Parse(RawGrammar{
+			"origin": []string{"fine", "#missing#"},
+		})
+		if !assert.Nil(t, err) {
+			return
+		}
+		it := g.Enumerate("origin", 0, 0)
+		assert.Equal(t, []string{"fine"}, collect(it))
+		assert.Equal(t, ErrorNameNotFound{"missing"}, it.Err())
+	})
+}
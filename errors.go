@@ -3,11 +3,14 @@ package tracerygo
 import (
 	"errors"
 	"fmt"
+	"strings"
 )
 
 var (
 	// This is returned when a modifier doesn't write as many bytes as it expected to write
 	ErrUnexpectedNumberOfBytesWritten = errors.New("Unexpected number of bytes written")
+	// This is returned by a lookup function when it doesn't know a name, as opposed to failing to look it up; it's reported as ErrorNameNotFound rather than ErrorLookup
+	ErrLookupNotFound = errors.New("Name not found by lookup")
 )
 
 // This error wraps an error occuring in the underlying stream when writing
@@ -84,3 +87,139 @@ type ErrorInField struct {
 func (f ErrorInField) Error() string {
 	return fmt.Sprintf("in field '%s': %s", f.FieldName, f.Underlying)
 }
+
+// This error wraps an error that occurs when evaluating a batch, and decorates it with the seed that failed
+type ErrorInSeed struct {
+	Seed       int64
+	Underlying error
+}
+
+// Serializes the error message
+func (s ErrorInSeed) Error() string {
+	return fmt.Sprintf("with seed %d: %s", s.Seed, s.Underlying)
+}
+
+// This error occurs when a generator of distinct outputs can't find any more; Produced is how many distinct outputs were found in total
+type ErrorExhausted struct {
+	Name     string
+	Produced int
+}
+
+// Serializes the error message
+func (e ErrorExhausted) Error() string {
+	return fmt.Sprintf("'%s' is exhausted after %d distinct outputs", e.Name, e.Produced)
+}
+
+// This error occurs when a generator walking the grammar with a depth cap runs out of distinct outputs, but skipped some expansions for being too deep; there may be more
+type ErrorTruncated struct {
+	Name     string
+	Produced int
+}
+
+// Serializes the error message
+func (e ErrorTruncated) Error() string {
+	return fmt.Sprintf("'%s' has no more distinct outputs within the depth limit after %d, but deeper ones were skipped", e.Name, e.Produced)
+}
+
+// This error occurs when no sequence of rule choices for a name can produce an output
+type ErrorNotInLanguage struct {
+	Name   string
+	Output string
+}
+
+// Serializes the error message
+func (l ErrorNotInLanguage) Error() string {
+	return fmt.Sprintf("'%s' cannot produce %q", l.Name, l.Output)
+}
+
+// This error occurs when replaying a tape that doesn't fit the grammar; either it ran out, it names a rule that doesn't exist, or it has choices left over at the end, in which case there's no Name
+type ErrorTapeMismatch struct {
+	Position int
+	Name     string
+}
+
+// Serializes the error message
+func (t ErrorTapeMismatch) Error() string {
+	if t.Name == "" {
+		return fmt.Sprintf("tape has choices left over after choice %d", t.Position)
+	}
+	return fmt.Sprintf("tape doesn't fit the grammar at choice %d for '%s'", t.Position, t.Name)
+}
+
+// This error occurs during parsing when a conditional (e.g. '[if hero==robot:#mechVerb#|#verb#]') can't be understood, or during evaluation when the name it compares has no single value; the reason says what was expected
+type ErrorMalformedCondition struct {
+	Condition string
+	Reason    string
+}
+
+// Serializes the error message
+func (c ErrorMalformedCondition) Error() string {
+	return fmt.Sprintf("malformed condition '[%s]': %s", c.Condition, c.Reason)
+}
+
+// This error occurs during parsing when a built-in generator (e.g. '#rand(1,100)#' or '#dice(3d6)#') can't be understood; the reason says what was expected
+type ErrorMalformedGenerator struct {
+	Call   string
+	Reason string
+}
+
+// Serializes the error message
+func (g ErrorMalformedGenerator) Error() string {
+	return fmt.Sprintf("malformed generator '%s': %s", g.Call, g.Reason)
+}
+
+// This error occurs if a data path (e.g. '#$user.firstName#') can't be followed through the data given to the evaluation; the reason says where it stopped
+type ErrorPathNotFound struct {
+	Path   string
+	Reason string
+}
+
+// Serializes the error message
+func (p ErrorPathNotFound) Error() string {
+	return fmt.Sprintf("'%s' was not found in the data: %s", p.Path, p.Reason)
+}
+
+// This error occurs when reading a gettext PO file that can't be understood; the reason says what was expected
+type ErrorMalformedPO struct {
+	Line   int
+	Reason string
+}
+
+// Serializes the error message
+func (p ErrorMalformedPO) Error() string {
+	return fmt.Sprintf("malformed PO file at line %d: %s", p.Line, p.Reason)
+}
+
+// This error occurs if a pronoun declaration (e.g. '[pronouns:she]') names a set that isn't known and isn't written out like 'xe/xem/xyr/xyrs/xemself'
+type ErrorUnknownPronouns struct {
+	Key   string
+	Value string
+}
+
+// Serializes the error message
+func (p ErrorUnknownPronouns) Error() string {
+	return fmt.Sprintf("'%s' in '%s' isn't a known pronoun set; use they, she, he, it, one added with WithPronouns, or write the set out like 'xe/xem/xyr/xyrs/xemself'", p.Value, p.Key)
+}
+
+// This error occurs if a tag filter (e.g. '#animal{small}#' or WithTagFilter) leaves none of a name's rules to pick from
+type ErrorNoMatchingRules struct {
+	Name     string
+	Filter   []string
+	Excluded []string
+}
+
+// Serializes the error message
+func (n ErrorNoMatchingRules) Error() string {
+	return fmt.Sprintf("none of the rules for '%s' match the tag filter {%s} with {%s} excluded", n.Name, strings.Join(n.Filter, ","), strings.Join(n.Excluded, ","))
+}
+
+// This error occurs when none of the attempts made by GenerateWhere produce an output the predicate accepts
+type ErrorUnsatisfied struct {
+	Name     string
+	Attempts int
+}
+
+// Serializes the error message
+func (u ErrorUnsatisfied) Error() string {
+	return fmt.Sprintf("no output of '%s' satisfied the predicate after %d attempts", u.Name, u.Attempts)
+}
@@ -0,0 +1,68 @@
+package tracerygo
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+// This builds a contiguous range of seeds, starting at start and counting up, for use with Generate; a count below 1 gives an empty range
+func SeedRange(start int64, count int) []int64 {
+	if count < 0 {
+		count = 0
+	}
+	seeds := make([]int64, count)
+	for i := range seeds {
+		seeds[i] = start + int64(i)
+	}
+	return seeds
+}
+
+// This evaluates the same field once per seed, fanning the evaluations out across a pool of workers.
+// Results come back in the same order as the seeds, and each one is identical to what Evaluate returns for that seed. The context is passed on to each evaluation, so providers can be cancelled too
+func (g Grammar) Generate(ctx context.Context, name string, index int, seeds []int64, workers int) ([]string, error) {
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > len(seeds) {
+		workers = len(seeds)
+	}
+
+	results := make([]string, len(seeds))
+	errs := make([]error, len(seeds))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				var sb strings.Builder
+				errs[i] = g.streamingEvaluate(&sb, name, index, seedRandom(seeds[i]), WithContext(ctx))
+				results[i] = sb.String()
+			}
+		}()
+	}
+
+feed:
+	for i := range seeds {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return results, err
+	}
+	for i, err := range errs {
+		if err != nil {
+			return results, ErrorInSeed{seeds[i], err}
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,66 @@
+package tracerygo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerate(t *testing.T) {
+	g, err := Parse(RawGrammar{
+		"origin": []string{"#a# #b#"},
+		"a":      []string{"red", "green", "blue", "yellow"},
+		"b":      []string{"fox", "owl", "cat", "dog", "eel"},
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	t.Run("matches single seed evaluation in order", func(t *testing.T) {
+		seeds := SeedRange(10, 50)
+		results, err := g.Generate(context.Background(), "origin", 0, seeds, 4)
+		if assert.Nil(t, err) && assert.Len(t, results, len(seeds)) {
+			for i, seed := range seeds {
+				expected, _ := g.Evaluate("origin", 0, seed)
+				assert.Equal(t, expected, results[i])
+			}
+		}
+	})
+	t.Run("no seeds", func(t *testing.T) {
+		results, err := g.Generate(context.Background(), "origin", 0, nil, 4)
+		assert.Nil(t, err)
+		assert.Empty(t, results)
+	})
+	t.Run("reports the failing seed", func(t *testing.T) {
+		_, err := g.Generate(context.Background(), "missing", 0, []int64{3}, 2)
+		if assert.IsType(t, ErrorInSeed{}, err) {
+			assert.Equal(t, int64(3), err.(ErrorInSeed).Seed)
+		}
+	})
+	t.Run("cancelled context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := g.Generate(ctx, "origin", 0, SeedRange(0, 10), 2)
+		assert.Equal(t, context.Canceled, err)
+	})
+	t.Run("context reaches each evaluation", func(t *testing.T) {
+		results, err := g.Generate(cancelledEvaluation{context.Background()}, "origin", 0, SeedRange(0, 3), 2)
+		assert.Equal(t, context.Canceled, err)
+		assert.Equal(t, []string{"", "", ""}, results)
+	})
+	t.Run("seed ranges", func(t *testing.T) {
+		assert.Equal(t, []int64{4, 5, 6}, SeedRange(4, 3))
+		assert.Empty(t, SeedRange(4, 0))
+		assert.Empty(t, SeedRange(4, -2))
+	})
+}
+
+// this is a context that never signals Done, so the seeds are all handed out, but reports it was cancelled to anything that checks
+type cancelledEvaluation struct {
+	context.Context
+}
+
+func (cancelledEvaluation) Err() error {
+	return context.Canceled
+}
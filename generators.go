@@ -0,0 +1,124 @@
+package tracerygo
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// this is a parsed built-in generator, e.g. 'rand(1,100)' or 'dice(3d6+2)'
+type generator struct {
+	call string
+	// for rand, the inclusive range
+	min int
+	max int
+	// for dice, how many dice of how many sides, and what's added to the total
+	dice  int
+	sides int
+	bonus int
+}
+
+const (
+	// these keep a single generator from doing an unreasonable amount of work
+	maxDice  = 1000
+	maxSides = 1000000
+)
+
+// this parses a name as a built-in generator call; isCall is false if it isn't a call to rand or dice
+func parseGenerator(call string) (gen generator, isCall bool, err error) {
+	open := strings.Index(call, "(")
+	if open < 0 || !strings.HasSuffix(call, ")") {
+		return gen, false, nil
+	}
+	gen.call = call
+	fn := call[:open]
+	args := call[open+1 : len(call)-1]
+	switch fn {
+	case "rand":
+		bounds := strings.Split(args, ",")
+		if len(bounds) != 2 {
+			return gen, true, ErrorMalformedGenerator{call, "expected two numbers, like rand(1,100)"}
+		}
+		var err1, err2 error
+		gen.min, err1 = strconv.Atoi(strings.TrimSpace(bounds[0]))
+		gen.max, err2 = strconv.Atoi(strings.TrimSpace(bounds[1]))
+		if err1 != nil || err2 != nil {
+			return gen, true, ErrorMalformedGenerator{call, "expected whole numbers, like rand(1,100)"}
+		}
+		if gen.min > gen.max {
+			return gen, true, ErrorMalformedGenerator{call, "expected the first number to be no bigger than the second"}
+		}
+		if uint64(gen.max-gen.min) >= math.MaxInt32 {
+			return gen, true, ErrorMalformedGenerator{call, "the range is too large"}
+		}
+	case "dice":
+		spec := strings.TrimSpace(args)
+		if sign := strings.IndexAny(spec, "+-"); sign >= 0 {
+			bonus, err := strconv.Atoi(spec[sign:])
+			if err != nil {
+				return gen, true, ErrorMalformedGenerator{call, "expected a whole number after the '+' or '-', like dice(2d6+3)"}
+			}
+			gen.bonus = bonus
+			spec = spec[:sign]
+		}
+		d := strings.Index(spec, "d")
+		if d < 0 {
+			return gen, true, ErrorMalformedGenerator{call, "expected dice like dice(3d6)"}
+		}
+		gen.dice = 1
+		if d > 0 {
+			if gen.dice, err = strconv.Atoi(spec[:d]); err != nil {
+				return gen, true, ErrorMalformedGenerator{call, "expected a whole number of dice, like dice(3d6)"}
+			}
+		}
+		if gen.sides, err = strconv.Atoi(spec[d+1:]); err != nil {
+			return gen, true, ErrorMalformedGenerator{call, "expected a whole number of sides, like dice(3d6)"}
+		}
+		if gen.dice < 1 || gen.dice > maxDice || gen.sides < 1 || gen.sides > maxSides {
+			return gen, true, ErrorMalformedGenerator{call, "expected between 1 and 1000 dice with between 1 and 1000000 sides"}
+		}
+	default:
+		// anything else shaped like a call is an ordinary name, resolved like any other
+		return gen, false, nil
+	}
+	return gen, true, nil
+}
+
+// this draws a number from the generator; each die is its own choice, so tapes and enumeration cover them like rules
+func (e *Evaluation) generate(gen generator, path string) (Node, error) {
+	var value int
+	if gen.dice == 0 {
+		i, err := e.draw(gen.call, path, gen.max-gen.min+1)
+		if err != nil {
+			return Node{}, err
+		}
+		value = gen.min + i
+	} else {
+		value = gen.bonus
+		for d := 0; d < gen.dice; d++ {
+			i, err := e.draw(gen.call, path+"/"+strconv.Itoa(d), gen.sides)
+			if err != nil {
+				return Node{}, err
+			}
+			value += i + 1
+		}
+	}
+	return Node{Parts: []interface{}{strconv.Itoa(value)}}, nil
+}
+
+// this is how many different sequences of choices the generator can make
+func (gen generator) expansions() float64 {
+	if gen.dice == 0 {
+		return float64(gen.max - gen.min + 1)
+	}
+	return math.Pow(float64(gen.sides), float64(gen.dice))
+}
+
+// this is roughly the expected length of the output, taken from the shortest and longest outputs
+func (gen generator) expectedLength() float64 {
+	low, high := gen.min, gen.max
+	if gen.dice != 0 {
+		low, high = gen.dice+gen.bonus, gen.dice*gen.sides+gen.bonus
+	}
+	return float64(len(strconv.Itoa(low))+len(strconv.Itoa(high))) / 2
+}
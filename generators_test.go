@@ -0,0 +1,140 @@
+package tracerygo
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseGenerator(t *testing.T) {
+	assert := assert.New(t)
+
+	gen, isCall, err := parseGenerator("rand(-5, 12)")
+	assert.True(isCall)
+	assert.Nil(err)
+	assert.Equal(generator{call: "rand(-5, 12)", min: -5, max: 12}, gen)
+
+	gen, isCall, err = parseGenerator("dice(3d6+2)")
+	assert.True(isCall)
+	assert.Nil(err)
+	assert.Equal(generator{call: "dice(3d6+2)", dice: 3, sides: 6, bonus: 2}, gen)
+
+	gen, _, err = parseGenerator("dice(d20-1)")
+	assert.Nil(err)
+	assert.Equal(generator{call: "dice(d20-1)", dice: 1, sides: 20, bonus: -1}, gen)
+
+	for _, name := range []string{"animal", "roll(1,2)", "greet(x)"} {
+		_, isCall, err = parseGenerator(name)
+		assert.False(isCall, name)
+		assert.Nil(err, name)
+	}
+
+	for _, call := range []string{"rand(1)", "rand(a,b)", "rand(5,1)", "dice(3)", "dice(xd6)", "dice(0d6)", "dice(2d6+x)"} {
+		_, isCall, err := parseGenerator(call)
+		assert.True(isCall, call)
+		assert.IsType(ErrorMalformedGenerator{}, err, call)
+	}
+
+	_, err = Parse(RawGrammar{"origin": []string{"#rand(9,1)#"}})
+	assert.Equal(ErrorInField{"origin[0]", ErrorMalformedGenerator{"rand(9,1)", "expected the first number to be no bigger than the second"}}, err)
+
+	// other names shaped like calls are looked up like any other name
+	g, err := Parse(RawGrammar{"origin": []string{"#greet(x)# #roll(1,2)#"}, "greet(x)": []string{"hello"}})
+	if !assert.Nil(err) {
+		return
+	}
+	var sb strings.Builder
+	e := NewEvaluation(&sb, WithGrammar(g), WithLookup(func(name string) (string, error) { return "[" + name + "]", nil }))
+	assert.Nil(e.Evaluate(symbolNode("origin")))
+	assert.Equal("hello [roll(1,2)]", sb.String())
+	_, err = g.Evaluate("origin", 0, 0)
+	assert.Equal(ErrorNameNotFound{"roll(1,2)"}, err)
+}
+
+func TestGenerators(t *testing.T) {
+	g, err := Parse(RawGrammar{
+		"origin": []string{"#rand(10,12)#|#dice(2d4+1)#"},
+		"small":  []string{"#rand(1,3).ordinal# #dice(1d2).words#"},
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	t.Run("in range and deterministic", func(t *testing.T) {
+		for seed := int64(0); seed < 50; seed++ {
+			result, err := g.Evaluate("origin", 0, seed)
+			assert.Nil(t, err)
+			again, _ := g.Evaluate("origin", 0, seed)
+			assert.Equal(t, result, again)
+
+			numbers := strings.Split(result, "|")
+			r, _ := strconv.Atoi(numbers[0])
+			d, _ := strconv.Atoi(numbers[1])
+			assert.True(t, r >= 10 && r <= 12, result)
+			assert.True(t, d >= 3 && d <= 9, result)
+		}
+	})
+	t.Run("enumerated and replayed", func(t *testing.T) {
+		assert.Equal(t, []string{"1st one", "1st two", "2nd one", "2nd two", "3rd one", "3rd two"}, collect(g.Enumerate("small", 0, 0)))
+
+		result, tape, err := g.EvaluateRecorded("origin", 0, 7)
+		assert.Nil(t, err)
+		assert.Len(t, tape, 3)
+		replayed, err := g.Replay("origin", 0, tape)
+		assert.Nil(t, err)
+		assert.Equal(t, result, replayed)
+	})
+	t.Run("analysed", func(t *testing.T) {
+		a := Analyze(g, "origin")
+		assert.Empty(t, a.External)
+		assert.Equal(t, 3.0*16, a.Symbols["origin"].Expansions)
+		assert.Equal(t, 6.0, a.Symbols["small"].Expansions)
+	})
+}
+
+func TestNumberModifiers(t *testing.T) {
+	for input, expected := range map[string]string{
+		"1": "1st", "2": "2nd", "3": "3rd", "4": "4th", "11": "11th", "12": "12th", "13": "13th",
+		"21": "21st", "102": "102nd", "111": "111th", "-3": "-3rd", "many": "many",
+	} {
+		assert.Equal(t, expected, ordinal(input))
+	}
+	for input, expected := range map[string]string{
+		"0":       "zero",
+		"7":       "seven",
+		"19":      "nineteen",
+		"40":      "forty",
+		"21":      "twenty-one",
+		"105":     "one hundred five",
+		"3412":    "three thousand four hundred twelve",
+		"2000000": "two million",
+		"-15":     "minus fifteen",
+		"3d6":     "3d6",
+	} {
+		assert.Equal(t, expected, numberWords(input))
+	}
+}
+
+func TestEmptyExpansions(t *testing.T) {
+	g, err := Parse(RawGrammar{
+		"empty":   []string{""},
+		"cond":    []string{"[if missing==x:yes]"},
+		"capital": []string{"#empty.capitalize.ordinal#"},
+		"article": []string{"#empty.a.words#"},
+		"branch":  []string{"#cond.capitalize.ordinal#"},
+		"plain":   []string{"#empty.capitalize##empty.a#"},
+		"later":   []string{"[x:#empty#]#x.capitalize.ordinal##x.a#!"},
+		"pair":    []string{"#empty#cat"},
+		"joined":  []string{"#pair.capitalize# #pair.a#"},
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+	for _, name := range []string{"capital", "article", "branch", "plain", "later", "joined"} {
+		result, err := g.Evaluate(name, 0, 0)
+		assert.Nil(t, err, name)
+		assert.Equal(t, map[string]string{"later": "!", "joined": "Cat a cat"}[name], result, name)
+	}
+}
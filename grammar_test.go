@@ -1,12 +1,35 @@
 package tracerygo
 
 import (
+	"errors"
 	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 )
 
+// this parses a rule and evaluates it on its own with the modifiers given, flushing the evaluation at the end
+func evaluateRule(rule string, modifiers ...EvaluationModifier) (string, error) {
+	n, err := parseRule(rule)
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	e := NewEvaluation(&sb, modifiers...)
+	err = e.Evaluate(n)
+	if err == nil {
+		err = e.Flush()
+	}
+	return sb.String(), err
+}
+
+// this is evaluateRule for rules that are expected to evaluate without an error
+func mustEvaluateRule(t *testing.T, rule string, modifiers ...EvaluationModifier) string {
+	result, err := evaluateRule(rule, modifiers...)
+	assert.Nil(t, err, rule)
+	return result
+}
+
 func TestEvaluate(t *testing.T) {
 	t.Run("string", func(t *testing.T) {
 		result := Node{
@@ -95,7 +118,7 @@ func TestEvaluate(t *testing.T) {
 				"hello ",
 				Substitution{
 					Variables: []Variable{
-						{"myWorld", []interface{}{Substitution{Key: "world2"}}},
+						{Key: "myWorld", Parts: []interface{}{Substitution{Key: "world2"}}},
 					},
 					Key: "world",
 				},
@@ -129,7 +152,7 @@ func TestEvaluate(t *testing.T) {
 	t.Run("substitution with global variables", func(t *testing.T) {
 		result := Node{
 			Variables: []Variable{
-				{"myWorld", []interface{}{Substitution{Key: "world2"}}},
+				{Key: "myWorld", Parts: []interface{}{Substitution{Key: "world2"}}},
 			},
 			Parts: []interface{}{
 				"hello ",
@@ -168,3 +191,102 @@ func TestEvaluate(t *testing.T) {
 		assert.Equal(t, "hello world and world, and world and world", sb.String())
 	})
 }
+
+func TestRuleSetVariables(t *testing.T) {
+	g, err := Parse(RawGrammar{
+		"origin": []string{"[animal:cat,dog,#bird#]#animal# #animal# #animal# #animal#"},
+		"bird":   []string{"owl", "wren"},
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+	seen := make(map[string]bool)
+	for seed := int64(0); seed < 20; seed++ {
+		result, err := g.Evaluate("origin", 0, seed)
+		assert.Nil(t, err)
+		for _, word := range strings.Split(result, " ") {
+			seen[word] = true
+		}
+		// the bird is picked once, when declared, so it can't change between references
+		assert.False(t, strings.Contains(result, "owl") && strings.Contains(result, "wren"))
+	}
+	assert.True(t, seen["cat"])
+	assert.True(t, seen["dog"])
+
+	a := Analyze(g, "origin")
+	assert.Equal(t, 2.0*3*3*3*3, a.Symbols["origin"].Expansions)
+}
+
+func TestLazyVariables(t *testing.T) {
+	g, err := Parse(RawGrammar{
+		"origin": []string{"[~adj:#mood#][fixed:#mood#]#adj#,#adj#,#adj#,#adj#|#fixed#,#fixed#"},
+		"mood":   []string{"sad", "glad", "mad", "bad"},
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+	rerolled := false
+	for seed := int64(0); seed < 20; seed++ {
+		result, err := g.Evaluate("origin", 0, seed)
+		assert.Nil(t, err)
+		halves := strings.Split(result, "|")
+		lazy := strings.Split(halves[0], ",")
+		eager := strings.Split(halves[1], ",")
+		assert.Equal(t, eager[0], eager[1])
+		for _, l := range lazy {
+			rerolled = rerolled || l != lazy[0]
+		}
+	}
+	assert.True(t, rerolled)
+
+	a := Analyze(g, "origin")
+	assert.Equal(t, 4.0*4*4*4*4, a.Symbols["origin"].Expansions)
+	assert.Equal(t, 5.0, a.Symbols["mood"].ExpectedUses)
+}
+
+func TestConditional(t *testing.T) {
+	g, err := Parse(RawGrammar{
+		"origin":   []string{"[hero:#kind#]the #hero# #action#"},
+		"action":   []string{"[if hero==robot:#mechVerb#|#verb#][if hero!=robot:!]"},
+		"kind":     []string{"robot", "knight"},
+		"mechVerb": []string{"whirs"},
+		"verb":     []string{"sings"},
+		"check":    []string{"[if missing==:unset|set]"},
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+	seen := make(map[string]bool)
+	for seed := int64(0); seed < 20; seed++ {
+		result, err := g.Evaluate("origin", 0, seed)
+		assert.Nil(t, err)
+		seen[result] = true
+	}
+	assert.Equal(t, map[string]bool{"the robot whirs": true, "the knight sings!": true}, seen)
+
+	result, err := g.Evaluate("check", 0, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, "unset", result)
+
+	// a condition only reads a single value, so it never draws a choice of its own
+	for seed := int64(0); seed < 20; seed++ {
+		_, tape, err := g.EvaluateRecorded("origin", 0, seed)
+		assert.Nil(t, err)
+		assert.Len(t, tape, 1)
+	}
+	for rule, reason := range map[string]string{
+		"[a:cat,dog]#a# [if a==cat:C|D]": "'a' has 2 rules to choose from, so it has no single value to compare",
+		"[if kind!=robot:C]":             "'kind' has 2 rules to choose from, so it has no single value to compare",
+		"[if rand(1,2)==1:C]":            "'rand(1,2)' is a generator, so it has no single value to compare",
+	} {
+		n, err := parseRule(rule)
+		if !assert.Nil(t, err, rule) {
+			continue
+		}
+		err = NewEvaluation(&strings.Builder{}, WithGrammar(g)).Evaluate(n)
+		var malformed ErrorMalformedCondition
+		if assert.True(t, errors.As(err, &malformed), rule) {
+			assert.Equal(t, reason, malformed.Reason, rule)
+		}
+	}
+}
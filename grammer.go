@@ -1,9 +1,12 @@
 package tracerygo
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"io"
 	"math/rand"
+	"strconv"
 	"strings"
 )
 
@@ -13,14 +16,23 @@ type Node struct {
 	Variables []Variable
 	// The parts to be evaluated; this is untyped but can contain strings, Substitutions, Evaluations, etc.
 	Parts []interface{}
+	// The tags a rule is annotated with (i.e. '{"text": "mouse", "tags": ["small"]}'), used to filter which rules can be picked
+	Tags []string
+	// Metadata about the rule, and about the symbol it's a rule of; the symbol's is shared by all of its rules. These aren't used when evaluating
+	Meta   Metadata
+	Symbol Metadata
 }
 
 // This represents a variable definition.
 type Variable struct {
-	// This is the key that can be used later (i.e. `Variable{"myVar", []interface{}{"value"}}` is equivalent to "[myVar:value]")
+	// This is the key that can be used later (i.e. `Variable{Key: "myVar", Parts: []interface{}{"value"}}` is equivalent to "[myVar:value]")
 	Key string
 	// The parts to be evaluated when it is looked up; this could be a lookup, a string, or similar. It's evaluated like a Node
 	Parts []interface{}
+	// When the variable is a rule set (i.e. "[animal:cat,dog]"), the parts of each rule; Parts is unused and each lookup picks one of the rules
+	Rules [][]interface{}
+	// When lazy (i.e. "[~adj:#mood#]"), the parts are kept as a rule and evaluated again on each lookup, rather than evaluated once when declared
+	Lazy bool
 }
 
 // This represents a substitution, e.g. '#myVar#' or '#[myVar:#sub#]value.s'
@@ -31,6 +43,33 @@ type Substitution struct {
 	Modifiers []int
 	// The key to lookup and replace this substitution with
 	Key string
+	// The tags a rule must have to be picked (i.e. '#animal{small}#'), or must not have if they start with '!'
+	Tags []string
+	// The character each modifier agrees with, in the same order as the modifiers (i.e. 'hero' for '.heroAgree'); this is nil when none of them name one
+	Agreement []string
+}
+
+// This represents a conditional, e.g. '[if hero==robot:#mechVerb#|#verb#]'
+type Conditional struct {
+	// The name whose value is compared; names that aren't declared compare as empty
+	Key string
+	// The text the value is compared against
+	Value string
+	// If the comparison is '!=' rather than '=='
+	Negate bool
+	// The node evaluated when the comparison holds
+	Then Node
+	// The node evaluated when it doesn't; this can be empty
+	Else Node
+}
+
+// this reports a condition on a name that has no single value to compare
+func (c Conditional) ambiguous(what string) error {
+	op := "=="
+	if c.Negate {
+		op = "!="
+	}
+	return ErrorMalformedCondition{"if " + c.Key + op + c.Value, fmt.Sprintf("'%s' %s, so it has no single value to compare", c.Key, what)}
 }
 
 // This is the bundled context for a single evaluation.
@@ -42,9 +81,42 @@ type Evaluation struct {
 	// this is a custom lookup function
 	lookup LookupFunction
 	// this is a custom random intreface
-	rand *rand.Rand
+	rand Random
 	// this is the output stream
 	out io.Writer
+	// this is a richer custom lookup, that returns rules to expand
+	provider Provider
+	// this is checked as the evaluation goes, so it can be cancelled or given a deadline
+	ctx context.Context
+	// these are the pronoun sets that can be bound, beyond the built-in ones, and the set bound for each character by its prefix ('' for plain 'pronouns')
+	pronounSets map[string]PronounSet
+	pronouns    map[string]PronounSet
+	// rules with any of these tags are never picked
+	excludedTags []string
+	// this is the language the modifiers follow the rules of; English is used when it isn't set
+	locale *Locale
+	// when html is set, values from outside the grammar are escaped and modifiers skip over markup
+	html bool
+	// these are the post processors given, and the stages they were built into around the output stream
+	processors []PostProcessor
+	stages     []Modifier
+	// this is structured data that '$' paths are resolved against
+	data interface{}
+	// these are the results of resolving names ahead of time, and how many workers to resolve them with when evaluating
+	prefetched map[string]prefetchResult
+	workers    int
+	// this overrides the random interface when picking which rule to use for a name; used to walk the grammar deterministically
+	choose func(name string, n int) (int, error)
+	// when replaying a tape, this checks it was used up once the evaluation is flushed
+	replayed func() error
+	// this is where choices are recorded, if they are being recorded
+	tape *Tape
+	// when derived, each expansion draws from a random stream derived from the seed and the path to that expansion rather than sharing one stream
+	derived bool
+	seed    int64
+	// this is the path to the expansion currently being evaluated, and how many times each name has been expanded directly within it
+	path        string
+	occurrences map[string]int
 }
 
 // An evaluation modifier, when passed in to create the evaluation, modifies it's internal state on creation. This can be used to give an optional paramter or some configuration value
@@ -57,6 +129,7 @@ func NewEvaluation(out io.Writer, modifiers ...EvaluationModifier) *Evaluation {
 		lookup:  nil,
 		out:     out,
 		rand:    nil,
+		ctx:     context.Background(),
 	}
 	for _, m := range modifiers {
 		m(e)
@@ -67,11 +140,25 @@ func NewEvaluation(out io.Writer, modifiers ...EvaluationModifier) *Evaluation {
 	if e.Grammar == nil {
 		e.Grammar = make(map[string][]Node)
 	}
+	// the first post processor given sees the output first, so the stages are built from the stream outwards
+	for i := len(e.processors) - 1; i >= 0; i-- {
+		stage := e.processors[i](e.out)
+		e.stages = append(e.stages, stage)
+		e.out = stage
+	}
 	return e
 }
 
+// This is the random interface an evaluation draws from; *rand.Rand satisfies it, as does the built-in SplitMix
+type Random interface {
+	// Returns a number in [0, n)
+	Intn(n int) int
+	// Returns a number in [0.0, 1.0)
+	Float64() float64
+}
+
 // This provides a custom random interface to an evaluation context
-func WithRandom(rand *rand.Rand) EvaluationModifier {
+func WithRandom(rand Random) EvaluationModifier {
 	return func(e *Evaluation) {
 		e.rand = rand
 	}
@@ -94,16 +181,31 @@ func WithLookup(fn LookupFunction) EvaluationModifier {
 	}
 }
 
-func (e *Evaluation) clone(out io.Writer, lookups []Variable) *Evaluation {
+func (e *Evaluation) clone(out io.Writer, lookups []Variable, path string) (*Evaluation, error) {
 	// shortcut if we're cloning but don't actually make any changes
-	if out == nil && lookups == nil {
-		return e
+	if out == nil && lookups == nil && path == e.path {
+		return e, nil
 	}
 
 	sube := &Evaluation{
-		out:     e.out,
-		rand:    e.rand,
-		Grammar: e.Grammar,
+		out:          e.out,
+		rand:         e.rand,
+		Grammar:      e.Grammar,
+		lookup:       e.lookup,
+		provider:     e.provider,
+		ctx:          e.ctx,
+		data:         e.data,
+		html:         e.html,
+		locale:       e.locale,
+		excludedTags: e.excludedTags,
+		pronounSets:  e.pronounSets,
+		pronouns:     e.pronouns,
+		prefetched:   e.prefetched,
+		choose:       e.choose,
+		tape:         e.tape,
+		derived:      e.derived,
+		seed:         e.seed,
+		path:         path,
 	}
 
 	if out != nil {
@@ -116,25 +218,62 @@ func (e *Evaluation) clone(out io.Writer, lookups []Variable) *Evaluation {
 			sube.Grammar[k] = v
 		}
 		for _, v := range lookups {
-			varn := Node{Parts: v.Parts}
-			var sb strings.Builder
-			t := sube.out
-			sube.out = &sb
-			sube.Evaluate(varn)
-			sube.Grammar[v.Key] = []Node{{Parts: []interface{}{sb.String()}}}
-			sube.out = t
+			rules := v.Rules
+			if rules == nil {
+				rules = [][]interface{}{v.Parts}
+			}
+			nodes := make([]Node, len(rules))
+			for i, r := range rules {
+				if v.Lazy {
+					nodes[i] = Node{Parts: r}
+					continue
+				}
+				var sb strings.Builder
+				vare := *sube
+				vare.out = &sb
+				vare.path = path + "/[" + v.Key + "]"
+				if i > 0 {
+					vare.path += strconv.Itoa(i)
+				}
+				vare.occurrences = nil
+				if err := vare.Evaluate(Node{Parts: r}); err != nil {
+					return nil, err
+				}
+				nodes[i] = Node{Parts: []interface{}{sb.String()}}
+			}
+			sube.Grammar[v.Key] = nodes
+			if prefix, ok := pronounPrefix(v.Key); ok {
+				if err := sube.bindPronouns(prefix, v.Key, nodes); err != nil {
+					return nil, err
+				}
+			}
 		}
 	}
 
-	return sube
+	return sube, nil
 }
 
 // This evaluates an entire node, writing it to the underlying stream directly
 func (e *Evaluation) Evaluate(n Node) error {
+	if e.workers > 0 {
+		// only the outermost evaluation prefetches; everything it reaches is covered
+		workers := e.workers
+		e.workers = 0
+		defer func() { e.workers = workers }()
+		if err := e.Prefetch(n, workers); err != nil {
+			return err
+		}
+	}
 	if len(n.Variables) != 0 {
-		e = e.clone(nil, n.Variables)
+		var err error
+		if e, err = e.clone(nil, n.Variables, e.path); err != nil {
+			return err
+		}
 	}
 	for _, abstract := range n.Parts {
+		if err := e.ctx.Err(); err != nil {
+			return err
+		}
 		switch v := abstract.(type) {
 		case string:
 			if _, err := e.out.Write([]byte(v)); err != nil {
@@ -143,7 +282,7 @@ func (e *Evaluation) Evaluate(n Node) error {
 		case Substitution:
 			var pipe io.Writer
 			var modifiers []Modifier
-			n, err := e.EvaluateName(v.Key)
+			n, path, err := e.pick(v.Key, v.Tags)
 			if err != nil {
 				return err
 			}
@@ -152,44 +291,191 @@ func (e *Evaluation) Evaluate(n Node) error {
 				modifiers = make([]Modifier, len(v.Modifiers))
 				pipe = e.out
 				for i, m := range v.Modifiers {
-					modifiers[i] = modifierLookup[m](pipe)
+					modifiers[i] = e.modifier(m, v.character(i))(pipe)
 					pipe = modifiers[i]
 				}
 			}
 
-			sube := e.clone(pipe, v.Variables)
+			sube, err := e.clone(pipe, v.Variables, path)
+			if err != nil {
+				return err
+			}
 			if err := sube.Evaluate(n); err != nil {
 				return err
 			}
 
+			if err := flushModifiers(modifiers); err != nil {
+				return err
+			}
 			for _, m := range modifiers {
 				if err := m.Finalize(); err != nil {
 					return err
 				}
 			}
+		case Conditional:
+			value, err := e.conditionValue(v)
+			if err != nil {
+				return err
+			}
+			branch := v.Else
+			if (value == v.Value) != v.Negate {
+				branch = v.Then
+			}
+			if err := e.Evaluate(branch); err != nil {
+				return err
+			}
 		default:
 		}
 	}
 	return nil
 }
 
+// this evaluates a name to the text it's compared with in a conditional; only a name with a single value (like a declared variable) can be compared, since picking
+// one of several would draw a choice the rest of the expansion doesn't see. A name that isn't found anywhere is empty
+func (e *Evaluation) conditionValue(v Conditional) (string, error) {
+	nodes, ok := e.Grammar[v.Key]
+	if !ok || len(nodes) == 0 {
+		if _, isCall, _ := parseGenerator(v.Key); isCall {
+			return "", v.ambiguous("is a generator")
+		}
+		var err error
+		if f, ok := e.prefetched[v.Key]; ok {
+			nodes, err = f.nodes, f.err
+		} else {
+			nodes, err = e.resolve(v.Key)
+		}
+		var notFound ErrorNameNotFound
+		if errors.As(err, &notFound) {
+			return "", nil
+		} else if err != nil {
+			return "", err
+		}
+	}
+	if len(nodes) != 1 {
+		return "", v.ambiguous(fmt.Sprintf("has %d rules to choose from", len(nodes)))
+	}
+	var sb strings.Builder
+	sube, err := e.clone(&sb, nil, e.path)
+	if err != nil {
+		return "", err
+	}
+	if err := sube.Evaluate(nodes[0]); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
 // This evaluates a specific name as if it were looking it up, writing it to the underlying stream directly
 func (e *Evaluation) EvaluateName(name string) (Node, error) {
+	n, _, err := e.pick(name, nil)
+	return n, err
+}
+
+// this picks the node to use for a name from the rules the tag filter allows, along with the path of the expansion it starts
+func (e *Evaluation) pick(name string, tags []string) (Node, string, error) {
+	path := e.path
+	if e.derived {
+		if e.occurrences == nil {
+			e.occurrences = make(map[string]int)
+		}
+		path = e.path + "/" + name + "#" + strconv.Itoa(e.occurrences[name])
+		e.occurrences[name]++
+	}
+
 	nodes, ok := e.Grammar[name]
 	if !ok || len(nodes) == 0 {
-		// not found; do we have a lookup function?
-		if e.lookup != nil {
-			value, err := e.lookup(name)
+		// not found; is it a built-in generator like 'rand(1,6)'?
+		if gen, isCall, err := parseGenerator(name); isCall {
 			if err != nil {
-				return Node{}, ErrorLookup{name, err}
+				return Node{}, path, err
 			}
-			return Node{Parts: []interface{}{value}}, nil
+			n, err := e.generate(gen, path)
+			return n, path, err
+		}
+		// was it fetched ahead of time?
+		var err error
+		if f, ok := e.prefetched[name]; ok {
+			nodes, err = f.nodes, f.err
 		} else {
-			return Node{}, ErrorNameNotFound{name}
+			nodes, err = e.resolve(name)
+		}
+		if err != nil {
+			return Node{}, path, err
 		}
+		// a single value isn't drawn, so lookups don't disturb the random stream
+		if len(nodes) == 1 && len(tags) == 0 && len(e.excludedTags) == 0 {
+			return nodes[0], path, nil
+		}
+	}
+	nodes, err := e.filterTags(name, nodes, tags)
+	if err != nil {
+		return Node{}, path, err
+	}
+	i, err := e.draw(name, path, len(nodes))
+	if err != nil {
+		return Node{}, path, err
+	}
+	return nodes[i], path, nil
+}
+
+// this resolves a name that isn't in the grammar; nothing from outside the grammar is trusted as markup, so it's escaped when writing html
+func (e *Evaluation) resolve(name string) ([]Node, error) {
+	nodes, err := e.resolveRaw(name)
+	if err == nil && e.html {
+		nodes = escapeNodes(nodes)
 	}
-	i := e.rand.Intn(len(nodes))
-	return nodes[i], nil
+	return nodes, err
+}
+
+// this resolves a name from the data, then the provider, then the lookup function
+func (e *Evaluation) resolveRaw(name string) ([]Node, error) {
+	// is it a path into the data?
+	if strings.HasPrefix(name, "$") {
+		return e.dataNodes(name)
+	}
+	// do we have a provider?
+	if e.provider != nil {
+		nodes, err := e.provide(name)
+		if err == nil {
+			return nodes, nil
+		} else if !errors.Is(err, ErrLookupNotFound) {
+			return nil, ErrorLookup{name, err}
+		}
+	}
+	// do we have a lookup function?
+	if e.lookup == nil {
+		return nil, ErrorNameNotFound{name}
+	}
+	value, err := e.lookup(name)
+	if errors.Is(err, ErrLookupNotFound) {
+		return nil, ErrorNameNotFound{name}
+	} else if err != nil {
+		return nil, ErrorLookup{name, err}
+	}
+	return []Node{{Parts: []interface{}{value}}}, nil
+}
+
+// this draws a number in [0, n) for a name from whichever source of choices the evaluation is using, recording it if needed
+func (e *Evaluation) draw(name string, path string, n int) (int, error) {
+	var i int
+	if e.choose != nil {
+		// a single option isn't really a choice, so it isn't passed on
+		if n == 1 {
+			return 0, nil
+		}
+		var err error
+		if i, err = e.choose(name, n); err != nil {
+			return 0, err
+		}
+	} else if e.derived {
+		i = derivedRandom(e.seed, path).Intn(n)
+	} else {
+		i = e.rand.Intn(n)
+	}
+	if e.tape != nil && n > 1 {
+		*e.tape = append(*e.tape, i)
+	}
+	return i, nil
 }
 
 // This represents a parsed and ready to use grammar
@@ -197,7 +483,16 @@ type Grammar map[string][]Node
 
 // This evaluates and directly streams it out to a specified writer
 func (g Grammar) StreamingEvaluate(out io.Writer, name string, index int, seed int64) error {
-	e := NewEvaluation(out, WithRandom(rand.New(rand.NewSource(seed))), WithGrammar(g))
+	return g.streamingEvaluate(out, name, index, seedRandom(seed))
+}
+
+// this provides the random interface used for a seed by the single seed interfaces
+func seedRandom(seed int64) EvaluationModifier {
+	return WithRandom(rand.New(rand.NewSource(seed)))
+}
+
+func (g Grammar) streamingEvaluate(out io.Writer, name string, index int, modifiers ...EvaluationModifier) error {
+	e := NewEvaluation(out, append([]EvaluationModifier{WithGrammar(g)}, modifiers...)...)
 
 	nodes, ok := g[name]
 	if !ok {
@@ -207,7 +502,10 @@ func (g Grammar) StreamingEvaluate(out io.Writer, name string, index int, seed i
 		return errors.New("Index out of bounds")
 	}
 	n := nodes[index]
-	return e.Evaluate(n)
+	if err := e.Evaluate(n); err != nil {
+		return err
+	}
+	return e.Flush()
 }
 
 // This calls StreamingEvaluate under the hood and buffers it to a string before returning
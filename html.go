@@ -0,0 +1,195 @@
+package tracerygo
+
+import (
+	"html"
+	"io"
+)
+
+// This writes the evaluation as html. Text authored in the grammar is trusted, so it can contain markup, but values from outside it (lookups, providers and data) are escaped.
+// The capitalize and article modifiers look past leading tags and entities to the first letter of text
+func WithHTML() EvaluationModifier {
+	return func(e *Evaluation) {
+		e.html = true
+	}
+}
+
+var markupModifiers = map[int]ModifierFunc{
+	modifierCapitalizeIndex:        ModifierCapitalizeMarkup,
+	modifierIndefiniteArticleIndex: ModifierIndefiniteArticleMarkup,
+}
+
+// this picks how a modifier is applied for this evaluation
+func (e *Evaluation) modifier(m int, character string) ModifierFunc {
+	if m == modifierAgreeIndex {
+		return e.agree(character)
+	}
+	if e.locale != nil {
+		if f := e.locale.modifier(m, e.html); f != nil {
+			return f
+		}
+	}
+	if e.html {
+		if f, ok := markupModifiers[m]; ok {
+			return f
+		}
+	}
+	return modifierLookup[m]
+}
+
+// this escapes all the text in nodes, including inside variables and conditionals
+func escapeNodes(nodes []Node) []Node {
+	escaped := make([]Node, len(nodes))
+	for i, n := range nodes {
+		escaped[i] = n
+		escaped[i].Variables = escapeVariables(n.Variables)
+		escaped[i].Parts = escapeParts(n.Parts)
+	}
+	return escaped
+}
+
+func escapeVariables(variables []Variable) []Variable {
+	if variables == nil {
+		return nil
+	}
+	escaped := make([]Variable, len(variables))
+	for i, v := range variables {
+		escaped[i] = Variable{Key: v.Key, Lazy: v.Lazy, Parts: escapeParts(v.Parts)}
+		if v.Rules != nil {
+			escaped[i].Rules = make([][]interface{}, len(v.Rules))
+			for k, r := range v.Rules {
+				escaped[i].Rules[k] = escapeParts(r)
+			}
+		}
+	}
+	return escaped
+}
+
+func escapeParts(parts []interface{}) []interface{} {
+	if parts == nil {
+		return nil
+	}
+	escaped := make([]interface{}, len(parts))
+	for i, abstract := range parts {
+		switch v := abstract.(type) {
+		case string:
+			escaped[i] = html.EscapeString(v)
+		case Substitution:
+			v.Variables = escapeVariables(v.Variables)
+			escaped[i] = v
+		case Conditional:
+			v.Then = escapeNodes([]Node{v.Then})[0]
+			v.Else = escapeNodes([]Node{v.Else})[0]
+			escaped[i] = v
+		default:
+			escaped[i] = abstract
+		}
+	}
+	return escaped
+}
+
+// this tracks whether a stream of html is inside a tag or an entity, so the first letter of text can be found
+type markupScanner struct {
+	inTag    bool
+	inEntity bool
+}
+
+// this returns the index of the first byte of text outside of tags and entities, or -1 if there isn't one in b
+func (m *markupScanner) text(b []byte) int {
+	for i, c := range b {
+		switch {
+		case m.inTag:
+			m.inTag = c != '>'
+		case m.inEntity:
+			m.inEntity = c != ';'
+		case c == '<':
+			m.inTag = true
+		case c == '&':
+			m.inEntity = true
+		default:
+			return i
+		}
+	}
+	return -1
+}
+
+type capitalizeMarkupPipe struct {
+	out     io.Writer
+	scanner markupScanner
+	done    bool
+}
+
+// This returns a modifier for capitalizing that skips over leading tags and entities, e.g. '<em>apple</em>' becomes '<em>Apple</em>'
+func ModifierCapitalizeMarkup(out io.Writer) Modifier {
+	return &capitalizeMarkupPipe{out: out}
+}
+
+// This writes to the underlying stream, capitalizing the first letter outside of a tag or entity
+func (p *capitalizeMarkupPipe) Write(b []byte) (int, error) {
+	if !p.done {
+		if i := p.scanner.text(b); i >= 0 {
+			p.done = true
+			s := []byte(string(b))
+			if s[i] >= 'a' && s[i] <= 'z' {
+				s[i] -= 'a' - 'A'
+			}
+			b = s
+		}
+	}
+	l, err := p.out.Write(b)
+	if err == nil && l != len(b) {
+		return 0, ErrUnexpectedNumberOfBytesWritten
+	}
+	return l, err
+}
+
+// This matches the interface but does nothing
+func (p *capitalizeMarkupPipe) Finalize() error {
+	return nil
+}
+
+type indefiniteArticleMarkupPipe struct {
+	out     io.Writer
+	scanner markupScanner
+	buffer  []byte
+	done    bool
+}
+
+// This returns a modifier for prefixing the indefinite article that skips over leading tags and entities, e.g. '<em>apple</em>' becomes 'an <em>apple</em>'
+func ModifierIndefiniteArticleMarkup(out io.Writer) Modifier {
+	return &indefiniteArticleMarkupPipe{out: out}
+}
+
+// This holds on to leading markup until the first letter of text is seen, then writes the suitable 'a' or 'an' in front of all of it
+func (p *indefiniteArticleMarkupPipe) Write(b []byte) (int, error) {
+	if p.done {
+		return p.out.Write(b)
+	}
+	i := p.scanner.text(b)
+	p.buffer = append(p.buffer, b...)
+	if i < 0 {
+		return len(b), nil
+	}
+	p.done = true
+	article := "a "
+	switch b[i] {
+	case 'a', 'e', 'i', 'o', 'u', 'A', 'E', 'I', 'O', 'U':
+		article = "an "
+	}
+	s := append([]byte(article), p.buffer...)
+	p.buffer = nil
+	l, err := p.out.Write(s)
+	if err == nil && l != len(s) {
+		return 0, ErrUnexpectedNumberOfBytesWritten
+	}
+	return len(b), err
+}
+
+// This writes out anything held on to if there was never any text
+func (p *indefiniteArticleMarkupPipe) Finalize() error {
+	if len(p.buffer) == 0 {
+		return nil
+	}
+	_, err := p.out.Write(p.buffer)
+	p.buffer = nil
+	return err
+}
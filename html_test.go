@@ -0,0 +1,59 @@
+package tracerygo
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTML(t *testing.T) {
+	g, err := Parse(RawGrammar{
+		"origin": []string{"<p>#fruit.capitalize.a# for #name#</p>"},
+		"fruit":  []string{"<em>apple</em>"},
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+	evaluate := func(rule string, modifiers ...EvaluationModifier) string {
+		return mustEvaluateRule(t, rule, append([]EvaluationModifier{WithGrammar(g)}, modifiers...)...)
+	}
+	lookup := WithLookup(MapLookup(map[string]string{"name": "<script>Tom & Jerry</script>"}))
+
+	t.Run("escapes values from outside the grammar", func(t *testing.T) {
+		assert.Equal(t, "<p>An <em>apple</em> for &lt;script&gt;Tom &amp; Jerry&lt;/script&gt;</p>", evaluate("#origin#", lookup, WithHTML()))
+		assert.Equal(t, "&lt;b&gt;", evaluate("#$tag#", WithData(map[string]string{"tag": "<b>"}), WithHTML()))
+		provider := WithProvider(ProviderFunc(func(ctx context.Context, name string) ([]string, error) {
+			return []string{"<i>#fruit#</i>[x:<u>]#x#"}, nil
+		}))
+		assert.Equal(t, "&lt;i&gt;<em>apple</em>&lt;/i&gt;&lt;u&gt;", evaluate("#remote#", provider, WithHTML()))
+	})
+	t.Run("leaves plain output alone", func(t *testing.T) {
+		assert.Equal(t, "<p>A <em>apple</em> for <script>Tom & Jerry</script></p>", evaluate("#origin#", lookup))
+	})
+	t.Run("modifiers skip markup", func(t *testing.T) {
+		for rule, expected := range map[string]string{
+			"[x:<b>&quot;]#x.capitalize#":  "<b>&quot;",
+			"[x:<b>egg</b>]#x.capitalize#": "<b>Egg</b>",
+			"[x:&amp;co]#x.capitalize#":    "&amp;Co",
+			"[x:<b>egg</b>]#x.a#":          "an <b>egg</b>",
+			"[x:<b></b>]#x.a#":             "<b></b>",
+			"[x:&lt;3]#x.a#":               "a &lt;3",
+		} {
+			assert.Equal(t, expected, evaluate(rule, WithHTML()), rule)
+		}
+	})
+	t.Run("text split across writes", func(t *testing.T) {
+		var sb strings.Builder
+		for _, m := range []Modifier{ModifierCapitalizeMarkup(&sb), ModifierIndefiniteArticleMarkup(&sb)} {
+			sb.Reset()
+			m.Write([]byte("<sp"))
+			m.Write([]byte("an>&a"))
+			m.Write([]byte("mp;"))
+			m.Write([]byte("orange"))
+			assert.Nil(t, m.Finalize())
+		}
+		assert.Equal(t, "an <span>&amp;orange", sb.String())
+	})
+}
@@ -0,0 +1,433 @@
+package tracerygo
+
+import (
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// This is the grammatical gender of a noun, used to pick the article that goes with it
+type Gender int
+
+const (
+	Masculine Gender = iota
+	Feminine
+	Neuter
+)
+
+// This is a set of language rules followed by the plural, article and capitalize modifiers ('.s', '.a', '.the' and '.capitalize').
+// Nouns are looked up in the lexicons first and then follow the language's usual patterns; add to the lexicons for words the patterns get wrong
+type Locale struct {
+	// The language tag, e.g. 'es'
+	Tag string
+	// Known genders of nouns, keyed by the lowercase noun
+	Genders map[string]Gender
+	// Known plurals of nouns, keyed by the lowercase noun
+	Plurals map[string]string
+
+	gender  func(word string) Gender
+	plural  func(word string) string
+	article func(word string, gender Gender, definite bool) string
+	// when set, adjectives follow the noun and agree with it, so every word of a phrase up to the first of these is made plural rather than only the last
+	pluralStops map[string]bool
+}
+
+// This makes the modifiers follow the rules of a language; without it they follow English rules
+func WithLocale(l *Locale) EvaluationModifier {
+	return func(e *Evaluation) {
+		e.locale = l
+	}
+}
+
+// This returns the locale for a language tag, e.g. 'es' or 'fr-CA'; only the language is used
+func LocaleFor(tag string) (*Locale, bool) {
+	language := strings.ToLower(strings.SplitN(strings.Replace(tag, "_", "-", -1), "-", 2)[0])
+	switch language {
+	case "en":
+		return EnglishLocale(), true
+	case "es":
+		return SpanishLocale(), true
+	case "fr":
+		return FrenchLocale(), true
+	case "de":
+		return GermanLocale(), true
+	}
+	return nil, false
+}
+
+// This returns the gender of a noun
+func (l *Locale) Gender(word string) Gender {
+	lower := strings.ToLower(word)
+	if g, ok := l.Genders[lower]; ok {
+		return g
+	}
+	if l.gender == nil {
+		return Neuter
+	}
+	return l.gender(lower)
+}
+
+// This returns the plural of a noun. In a phrase, the last word is made plural in English and German; in Spanish and French the adjectives after the noun agree with it,
+// so each word is made plural up to the first preposition or conjunction, e.g. 'gato negro' becomes 'gatos negros' and 'casa de papel' becomes 'casas de papel'
+func (l *Locale) Plural(s string) string {
+	if l.pluralStops == nil {
+		i := strings.LastIndexAny(s, " \t\n") + 1
+		return s[:i] + l.pluralWord(s[i:])
+	}
+	var sb strings.Builder
+	for rest := s; rest != ""; {
+		start := strings.IndexFunc(rest, func(r rune) bool { return !unicode.IsSpace(r) })
+		if start < 0 {
+			sb.WriteString(rest)
+			break
+		}
+		sb.WriteString(rest[:start])
+		rest = rest[start:]
+		end := strings.IndexFunc(rest, unicode.IsSpace)
+		if end < 0 {
+			end = len(rest)
+		}
+		word, lower := rest[:end], strings.ToLower(rest[:end])
+		if l.pluralStops[lower] || l.pluralStops[lower[:strings.Index(lower, "'")+1]] {
+			sb.WriteString(rest)
+			break
+		}
+		sb.WriteString(l.pluralWord(word))
+		rest = rest[end:]
+	}
+	return sb.String()
+}
+
+func (l *Locale) pluralWord(word string) string {
+	if word == "" {
+		return word
+	}
+	if p, ok := l.Plurals[strings.ToLower(word)]; ok {
+		return matchCapital(word, p)
+	}
+	return l.plural(word)
+}
+
+// This returns the article for a noun or phrase, including the space or apostrophe joining them, e.g. 'una ' or 'l”
+func (l *Locale) Article(s string, definite bool) string {
+	word := strings.Fields(s)
+	if len(word) == 0 {
+		return ""
+	}
+	return l.article(strings.ToLower(word[0]), l.Gender(word[0]), definite)
+}
+
+// This capitalizes the first letter, skipping over leading punctuation like '¿' or '«'
+func (l *Locale) Capitalize(s string) string {
+	for i, r := range s {
+		if unicode.IsDigit(r) {
+			return s
+		}
+		if unicode.IsLetter(r) {
+			return s[:i] + string(unicode.ToUpper(r)) + s[i+utf8.RuneLen(r):]
+		}
+	}
+	return s
+}
+
+// this returns the locale's version of a modifier, or nil if it doesn't have one
+func (l *Locale) modifier(m int, html bool) ModifierFunc {
+	var transform func(string) string
+	switch m {
+	case modifierCapitalizeIndex:
+		transform = func(s string) string {
+			i := textStart(s, html)
+			return s[:i] + l.Capitalize(s[i:])
+		}
+	case modifierIndefiniteArticleIndex, modifierDefiniteArticleIndex:
+		definite := m == modifierDefiniteArticleIndex
+		transform = func(s string) string {
+			return l.Article(s[textStart(s, html):], definite) + s
+		}
+	case modifierPluralizeIndex:
+		transform = l.Plural
+	default:
+		return nil
+	}
+	return func(out io.Writer) Modifier {
+		return &bufferedPipe{out: out, transform: transform}
+	}
+}
+
+// this is where the text starts, after any leading markup if it's html
+func textStart(s string, html bool) int {
+	if !html {
+		return 0
+	}
+	var scanner markupScanner
+	if i := scanner.text([]byte(s)); i >= 0 {
+		return i
+	}
+	return len(s)
+}
+
+// this capitalizes the first letter of replacement if the first letter of word is
+func matchCapital(word string, replacement string) string {
+	r, _ := utf8.DecodeRuneInString(word)
+	if !unicode.IsUpper(r) {
+		return replacement
+	}
+	first, size := utf8.DecodeRuneInString(replacement)
+	return string(unicode.ToUpper(first)) + replacement[size:]
+}
+
+func hasSuffix(word string, suffixes ...string) bool {
+	for _, suffix := range suffixes {
+		if strings.HasSuffix(word, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+func copyGenders(genders map[string]Gender) map[string]Gender {
+	c := make(map[string]Gender, len(genders))
+	for k, v := range genders {
+		c[k] = v
+	}
+	return c
+}
+
+func copyPlurals(plurals map[string]string) map[string]string {
+	c := make(map[string]string, len(plurals))
+	for k, v := range plurals {
+		c[k] = v
+	}
+	return c
+}
+
+// This returns the English rules, which are the same as the modifiers follow without a locale
+func EnglishLocale() *Locale {
+	return &Locale{
+		Tag:     "en",
+		Genders: make(map[string]Gender),
+		Plurals: make(map[string]string),
+		plural: func(word string) string {
+			return word + "s"
+		},
+		article: func(word string, gender Gender, definite bool) string {
+			if definite {
+				return "the "
+			}
+			switch word[0] {
+			case 'a', 'e', 'i', 'o', 'u':
+				return "an "
+			}
+			return "a "
+		},
+	}
+}
+
+var (
+	spanishGenders = map[string]Gender{
+		"día": Masculine, "mapa": Masculine, "problema": Masculine, "tema": Masculine, "sistema": Masculine, "planeta": Masculine, "idioma": Masculine,
+		"mano": Feminine, "foto": Feminine, "moto": Feminine, "radio": Feminine, "flor": Feminine, "miel": Feminine, "piel": Feminine, "sal": Feminine, "luz": Feminine, "voz": Feminine, "nariz": Feminine, "paz": Feminine, "noche": Feminine, "leche": Feminine, "calle": Feminine, "nube": Feminine, "llave": Feminine, "fuente": Feminine, "gente": Feminine, "carne": Feminine, "suerte": Feminine, "muerte": Feminine,
+	}
+	// feminine nouns starting with a stressed 'a' take 'el' and 'un' in the singular
+	spanishStressedA = map[string]bool{
+		"agua": true, "alma": true, "arma": true, "ala": true, "ave": true, "aula": true, "asma": true, "ancla": true, "hambre": true, "hacha": true, "hada": true, "águila": true, "área": true,
+	}
+)
+
+// these end the part of a phrase that's made plural along with the noun
+var (
+	spanishPluralStops = map[string]bool{"de": true, "del": true, "con": true, "en": true, "para": true, "por": true, "sin": true, "a": true, "al": true, "que": true, "y": true, "o": true}
+	frenchPluralStops  = map[string]bool{"de": true, "d'": true, "du": true, "des": true, "à": true, "au": true, "aux": true, "en": true, "pour": true, "par": true, "sans": true, "avec": true, "que": true, "qu'": true, "et": true, "ou": true}
+)
+
+// This returns the Spanish rules
+func SpanishLocale() *Locale {
+	return &Locale{
+		Tag:     "es",
+		Genders: copyGenders(spanishGenders),
+		Plurals: make(map[string]string),
+		gender: func(word string) Gender {
+			if hasSuffix(word, "a", "ción", "sión", "dad", "tad", "tud", "umbre") {
+				return Feminine
+			}
+			return Masculine
+		},
+		plural:      spanishPlural,
+		pluralStops: spanishPluralStops,
+		article: func(word string, gender Gender, definite bool) string {
+			feminine := gender == Feminine && !spanishStressedA[word] && !strings.HasPrefix(word, "á") && !strings.HasPrefix(word, "há")
+			switch {
+			case definite && feminine:
+				return "la "
+			case definite:
+				return "el "
+			case feminine:
+				return "una "
+			}
+			return "un "
+		},
+	}
+}
+
+var spanishUnaccented = strings.NewReplacer("á", "a", "é", "e", "í", "i", "ó", "o", "ú", "u")
+
+func spanishPlural(word string) string {
+	lower := strings.ToLower(word)
+	last, size := utf8.DecodeLastRuneInString(lower)
+	// the accent on the last syllable moves off it when a syllable is added, e.g. 'canción' becomes 'canciones'
+	syllableAdded := func(suffix string) string {
+		stem := word
+		if i := strings.LastIndexAny(word, "áéíóú"); i >= 0 && utf8.RuneCountInString(word[i:]) <= 2 {
+			_, accent := utf8.DecodeRuneInString(word[i:])
+			stem = word[:i] + spanishUnaccented.Replace(word[i:i+accent]) + word[i+accent:]
+		}
+		return stem + suffix
+	}
+	switch {
+	case strings.ContainsRune("aeiouáéó", last):
+		return word + "s"
+	case last == 'í' || last == 'ú' || last == 'y':
+		return word + "es"
+	case last == 'z':
+		return word[:len(word)-size] + "ces"
+	case last == 's' || last == 'x':
+		// unstressed endings like 'lunes' or 'tórax' don't change
+		single := strings.IndexAny(lower, "aeiouáéíóú") == strings.LastIndexAny(lower, "aeiouáéíóú")
+		if single || strings.ContainsAny(lower[strings.LastIndexAny(lower, "aeiouáéíóú"):], "áéíóú") {
+			return syllableAdded("es")
+		}
+		return word
+	}
+	return syllableAdded("es")
+}
+
+var (
+	frenchGenders = map[string]Gender{
+		"musée": Masculine, "lycée": Masculine, "silence": Masculine, "été": Masculine, "génie": Masculine, "incendie": Masculine, "parapluie": Masculine, "squelette": Masculine,
+		"fleur": Feminine, "mer": Feminine, "main": Feminine, "fin": Feminine, "nuit": Feminine, "forêt": Feminine, "maison": Feminine, "chanson": Feminine, "école": Feminine, "leçon": Feminine, "façon": Feminine, "eau": Feminine, "peau": Feminine, "clé": Feminine, "dent": Feminine, "mort": Feminine, "faim": Feminine, "voix": Feminine, "croix": Feminine, "paix": Feminine, "table": Feminine, "porte": Feminine, "femme": Feminine, "lune": Feminine, "terre": Feminine, "rose": Feminine, "pomme": Feminine, "plume": Feminine,
+	}
+	frenchPlurals = map[string]string{
+		"œil": "yeux", "ciel": "cieux", "travail": "travaux", "bail": "baux", "corail": "coraux", "émail": "émaux", "vitrail": "vitraux",
+		"bijou": "bijoux", "caillou": "cailloux", "chou": "choux", "genou": "genoux", "hibou": "hiboux", "joujou": "joujoux", "pou": "poux",
+		"bal": "bals", "carnaval": "carnavals", "chacal": "chacals", "festival": "festivals", "récital": "récitals", "régal": "régals",
+		"pneu": "pneus", "bleu": "bleus", "landau": "landaus",
+	}
+	// words starting with an aspirated 'h' don't elide the article
+	frenchAspiratedH = map[string]bool{
+		"héros": true, "haricot": true, "hibou": true, "hache": true, "honte": true, "hasard": true, "haine": true, "hall": true, "hamac": true, "hangar": true, "harpe": true, "hauteur": true, "homard": true, "huit": true,
+	}
+)
+
+// This returns the French rules
+func FrenchLocale() *Locale {
+	return &Locale{
+		Tag:     "fr",
+		Genders: copyGenders(frenchGenders),
+		Plurals: copyPlurals(frenchPlurals),
+		gender: func(word string) Gender {
+			if hasSuffix(word, "tion", "sion", "té", "ette", "ence", "ance", "ure", "ie", "ée", "ade", "ise", "euse", "trice", "ière", "elle", "esse", "ine", "onne") {
+				return Feminine
+			}
+			return Masculine
+		},
+		pluralStops: frenchPluralStops,
+		plural: func(word string) string {
+			lower := strings.ToLower(word)
+			switch {
+			case hasSuffix(lower, "s", "x", "z"):
+				return word
+			case hasSuffix(lower, "eau", "au", "eu"):
+				return word + "x"
+			case hasSuffix(lower, "al"):
+				return word[:len(word)-1] + "ux"
+			}
+			return word + "s"
+		},
+		article: func(word string, gender Gender, definite bool) string {
+			first, _ := utf8.DecodeRuneInString(word)
+			elided := strings.ContainsRune("aeiouyàâäéèêëîïôöùûüœæ", first) || first == 'h' && !frenchAspiratedH[word]
+			switch {
+			case definite && elided:
+				return "l'"
+			case definite && gender == Feminine:
+				return "la "
+			case definite:
+				return "le "
+			case gender == Feminine:
+				return "une "
+			}
+			return "un "
+		},
+	}
+}
+
+var (
+	germanGenders = map[string]Gender{
+		"mann": Masculine, "hund": Masculine, "tisch": Masculine, "baum": Masculine, "tag": Masculine, "vater": Masculine, "bruder": Masculine, "apfel": Masculine, "vogel": Masculine, "garten": Masculine, "fuß": Masculine, "käse": Masculine, "name": Masculine, "junge": Masculine,
+		"frau": Feminine, "stadt": Feminine, "welt": Feminine, "nacht": Feminine, "hand": Feminine, "mutter": Feminine, "tochter": Feminine, "maus": Feminine, "regel": Feminine, "feder": Feminine,
+		"kind": Neuter, "haus": Neuter, "buch": Neuter, "auto": Neuter, "jahr": Neuter, "wasser": Neuter, "zimmer": Neuter, "fenster": Neuter, "auge": Neuter, "ende": Neuter, "thema": Neuter, "bett": Neuter, "schiff": Neuter, "pferd": Neuter, "land": Neuter, "licht": Neuter,
+	}
+	germanPlurals = map[string]string{
+		"mann": "männer", "haus": "häuser", "buch": "bücher", "kind": "kinder", "baum": "bäume", "stadt": "städte", "nacht": "nächte", "hand": "hände",
+		"mutter": "mütter", "vater": "väter", "bruder": "brüder", "tochter": "töchter", "apfel": "äpfel", "vogel": "vögel", "garten": "gärten", "maus": "mäuse",
+		"fuß": "füße", "frau": "frauen", "land": "länder", "thema": "themen", "auge": "augen", "name": "namen", "junge": "jungen",
+	}
+)
+
+// This returns the German rules; articles are in the nominative case
+func GermanLocale() *Locale {
+	l := &Locale{
+		Tag:     "de",
+		Genders: copyGenders(germanGenders),
+		Plurals: copyPlurals(germanPlurals),
+		gender: func(word string) Gender {
+			switch {
+			case hasSuffix(word, "chen", "lein", "um", "ment", "tum"):
+				return Neuter
+			case hasSuffix(word, "ung", "heit", "keit", "schaft", "ion", "tät", "ik", "ei", "ie", "ur", "enz", "anz", "erin", "e"):
+				return Feminine
+			}
+			return Masculine
+		},
+		article: func(word string, gender Gender, definite bool) string {
+			switch {
+			case definite && gender == Feminine:
+				return "die "
+			case definite && gender == Neuter:
+				return "das "
+			case definite:
+				return "der "
+			case gender == Feminine:
+				return "eine "
+			}
+			return "ein "
+		},
+	}
+	l.plural = func(word string) string {
+		lower := strings.ToLower(word)
+		switch {
+		case hasSuffix(lower, "erin"):
+			return word + "nen"
+		case hasSuffix(lower, "nis"):
+			return word + "se"
+		case hasSuffix(lower, "um"):
+			return word[:len(word)-2] + "en"
+		case hasSuffix(lower, "a", "i", "o", "u", "y"):
+			return word + "s"
+		case hasSuffix(lower, "e"):
+			return word + "n"
+		case hasSuffix(lower, "chen", "lein"):
+			return word
+		case l.Gender(word) == Feminine:
+			if hasSuffix(lower, "el", "er") {
+				return word + "n"
+			}
+			return word + "en"
+		case hasSuffix(lower, "er", "el", "en"):
+			return word
+		}
+		return word + "e"
+	}
+	return l
+}
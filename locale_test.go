@@ -0,0 +1,96 @@
+package tracerygo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocaleFor(t *testing.T) {
+	for tag, expected := range map[string]string{"en": "en", "es-MX": "es", "fr_CA": "fr", "DE": "de"} {
+		l, ok := LocaleFor(tag)
+		if assert.True(t, ok, tag) {
+			assert.Equal(t, expected, l.Tag)
+		}
+	}
+	_, ok := LocaleFor("tlh")
+	assert.False(t, ok)
+
+	// each call has its own lexicons
+	a, b := SpanishLocale(), SpanishLocale()
+	a.Genders["pez"] = Feminine
+	assert.Equal(t, Masculine, b.Gender("pez"))
+}
+
+func TestEnglishLocale(t *testing.T) {
+	l := EnglishLocale()
+	rule := "[x:owl]#x.a# #x.the# #x.s# #x.capitalize# [y:cat]#y.a.capitalize#"
+	assert.Equal(t, mustEvaluateRule(t, rule), mustEvaluateRule(t, rule, WithLocale(l)))
+	assert.Equal(t, "an owl the owl owls Owl a Cat", mustEvaluateRule(t, rule, WithLocale(l)))
+}
+
+func TestSpanishLocale(t *testing.T) {
+	l := SpanishLocale()
+	for word, expected := range map[string]string{
+		"gato": "gatos", "casa": "casas", "café": "cafés", "rubí": "rubíes", "rey": "reyes", "lápiz": "lápices", "luz": "luces",
+		"canción": "canciones", "alemán": "alemanes", "árbol": "árboles", "mes": "meses", "autobús": "autobuses", "lunes": "lunes",
+		"gato negro": "gatos negros", "casa de papel": "casas de papel", "Luz  roja": "Luces  rojas",
+	} {
+		assert.Equal(t, expected, l.Plural(word), word)
+	}
+	for word, expected := range map[string]string{
+		"gato": "un gato|el gato", "casa": "una casa|la casa", "canción": "una canción|la canción", "ciudad": "una ciudad|la ciudad",
+		"día": "un día|el día", "mano": "una mano|la mano", "agua": "un agua|el agua", "hambre": "un hambre|el hambre",
+	} {
+		assert.Equal(t, expected, mustEvaluateRule(t, "[x:"+word+"]#x.a#|#x.the#", WithLocale(l)), word)
+	}
+	assert.Equal(t, "¿Qué? ¡Ágil!", mustEvaluateRule(t, "[x:¿qué?][y:¡ágil!]#x.capitalize# #y.capitalize#", WithLocale(l)))
+	assert.Equal(t, "Unas|El gato", mustEvaluateRule(t, "[x:unas][y:gato]#x.capitalize#|#y.capitalize.the#", WithLocale(l)))
+}
+
+func TestFrenchLocale(t *testing.T) {
+	l := FrenchLocale()
+	for word, expected := range map[string]string{
+		"chat": "chats", "prix": "prix", "nez": "nez", "bateau": "bateaux", "jeu": "jeux", "cheval": "chevaux",
+		"festival": "festivals", "œil": "yeux", "bijou": "bijoux", "Pneu": "Pneus",
+		"chat noir": "chats noirs", "chef d'œuvre": "chefs d'œuvre", "pomme de terre": "pommes de terre",
+	} {
+		assert.Equal(t, expected, l.Plural(word), word)
+	}
+	for word, expected := range map[string]string{
+		"chat": "un chat|le chat", "maison": "une maison|la maison", "arbre": "un arbre|l'arbre", "école": "une école|l'école",
+		"homme": "un homme|l'homme", "héros": "un héros|le héros", "liberté": "une liberté|la liberté", "musée": "un musée|le musée",
+	} {
+		assert.Equal(t, expected, mustEvaluateRule(t, "[x:"+word+"]#x.a#|#x.the#", WithLocale(l)), word)
+	}
+	assert.Equal(t, "« Été »", mustEvaluateRule(t, "[x:« été »]#x.capitalize#", WithLocale(l)))
+}
+
+func TestGermanLocale(t *testing.T) {
+	l := GermanLocale()
+	for word, expected := range map[string]string{
+		"Hund": "Hunde", "Mann": "Männer", "Frau": "Frauen", "Zeitung": "Zeitungen", "Blume": "Blumen", "Lehrerin": "Lehrerinnen",
+		"Mädchen": "Mädchen", "Lehrer": "Lehrer", "Auto": "Autos", "Museum": "Museen", "Ergebnis": "Ergebnisse", "Regel": "Regeln",
+	} {
+		assert.Equal(t, expected, l.Plural(word), word)
+	}
+	for word, expected := range map[string]string{
+		"Hund": "ein Hund|der Hund", "Katze": "eine Katze|die Katze", "Kind": "ein Kind|das Kind", "Mädchen": "ein Mädchen|das Mädchen",
+		"Freiheit": "eine Freiheit|die Freiheit", "Lehrer": "ein Lehrer|der Lehrer", "Zentrum": "ein Zentrum|das Zentrum",
+	} {
+		assert.Equal(t, expected, mustEvaluateRule(t, "[x:"+word+"]#x.a#|#x.the#", WithLocale(l)), word)
+	}
+	assert.Equal(t, "Über", mustEvaluateRule(t, "[x:über]#x.capitalize#", WithLocale(l)))
+}
+
+func TestLocaleMarkup(t *testing.T) {
+	l := FrenchLocale()
+	assert.Equal(t, "l'<em>École</em>", mustEvaluateRule(t, "[x:<em>école</em>]#x.the.capitalize#", WithHTML(), WithLocale(l)))
+}
+
+func TestChainedModifiers(t *testing.T) {
+	assert.Equal(t, "walksed walkeds", mustEvaluateRule(t, "[x:walk]#x.s.ed# #x.ed.s#"))
+	assert.Equal(t, "21st Three", mustEvaluateRule(t, "[x:21][y:3]#x.capitalize.words.ordinal# #y.capitalize.ordinal.words#"))
+	l := SpanishLocale()
+	assert.Equal(t, "Un gato|El gato|Gatos", mustEvaluateRule(t, "[x:gato]#x.capitalize.a#|#x.capitalize.the#|#x.capitalize.s#", WithLocale(l)))
+}
@@ -0,0 +1,75 @@
+package tracerygo
+
+import (
+	"errors"
+	"os"
+	"sync"
+	"time"
+)
+
+// this is swapped out in tests to control time
+var now = time.Now
+
+// This combines lookups, trying each in order until one knows the name; a lookup that fails with anything other than ErrLookupNotFound stops the chain
+func ChainLookups(lookups ...LookupFunction) LookupFunction {
+	return func(name string) (string, error) {
+		for _, fn := range lookups {
+			value, err := fn(name)
+			if !errors.Is(err, ErrLookupNotFound) {
+				return value, err
+			}
+		}
+		return "", ErrLookupNotFound
+	}
+}
+
+type cachedValue struct {
+	value   string
+	err     error
+	expires time.Time
+}
+
+// This remembers the results of a lookup, including names it didn't know, so each name is only looked up once.
+// Results are kept for the ttl, or forever if it's 0; create it alongside each evaluation to cache per evaluation. It's safe to use concurrently
+func CachedLookup(fn LookupFunction, ttl time.Duration) LookupFunction {
+	var mu sync.Mutex
+	cache := make(map[string]cachedValue)
+	return func(name string) (string, error) {
+		mu.Lock()
+		cached, ok := cache[name]
+		mu.Unlock()
+		if ok && (ttl == 0 || now().Before(cached.expires)) {
+			return cached.value, cached.err
+		}
+
+		value, err := fn(name)
+		if err != nil && !errors.Is(err, ErrLookupNotFound) {
+			// real failures might be temporary, so they aren't remembered
+			return value, err
+		}
+		mu.Lock()
+		cache[name] = cachedValue{value, err, now().Add(ttl)}
+		mu.Unlock()
+		return value, err
+	}
+}
+
+// This looks names up in a map
+func MapLookup(values map[string]string) LookupFunction {
+	return func(name string) (string, error) {
+		if value, ok := values[name]; ok {
+			return value, nil
+		}
+		return "", ErrLookupNotFound
+	}
+}
+
+// This looks names up in the environment, with a prefix; e.g. with the prefix 'STORY_', '#hero#' is read from STORY_hero
+func EnvLookup(prefix string) LookupFunction {
+	return func(name string) (string, error) {
+		if value, ok := os.LookupEnv(prefix + name); ok {
+			return value, nil
+		}
+		return "", ErrLookupNotFound
+	}
+}
@@ -0,0 +1,98 @@
+package tracerygo
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLookups(t *testing.T) {
+	failure := errors.New("remote is down")
+
+	t.Run("map", func(t *testing.T) {
+		fn := MapLookup(map[string]string{"hero": "Ada"})
+		value, err := fn("hero")
+		assert.Nil(t, err)
+		assert.Equal(t, "Ada", value)
+		_, err = fn("villain")
+		assert.Equal(t, ErrLookupNotFound, err)
+	})
+	t.Run("env", func(t *testing.T) {
+		os.Setenv("TRACERYGO_TEST_hero", "Grace")
+		defer os.Unsetenv("TRACERYGO_TEST_hero")
+		fn := EnvLookup("TRACERYGO_TEST_")
+		value, err := fn("hero")
+		assert.Nil(t, err)
+		assert.Equal(t, "Grace", value)
+		_, err = fn("villain")
+		assert.Equal(t, ErrLookupNotFound, err)
+	})
+	t.Run("chain", func(t *testing.T) {
+		fn := ChainLookups(
+			MapLookup(map[string]string{"hero": "Ada"}),
+			func(name string) (string, error) {
+				if name == "broken" {
+					return "", failure
+				}
+				return "", ErrLookupNotFound
+			},
+			MapLookup(map[string]string{"hero": "Grace", "villain": "Moriarty"}),
+		)
+		value, _ := fn("hero")
+		assert.Equal(t, "Ada", value)
+		value, _ = fn("villain")
+		assert.Equal(t, "Moriarty", value)
+		_, err := fn("broken")
+		assert.Equal(t, failure, err)
+		_, err = fn("sidekick")
+		assert.Equal(t, ErrLookupNotFound, err)
+	})
+	t.Run("cached", func(t *testing.T) {
+		calls := 0
+		fail := false
+		fn := CachedLookup(func(name string) (string, error) {
+			calls++
+			if fail {
+				return "", failure
+			}
+			if name == "hero" {
+				return "Ada", nil
+			}
+			return "", ErrLookupNotFound
+		}, time.Minute)
+
+		current := time.Unix(0, 0)
+		now = func() time.Time { return current }
+		defer func() { now = time.Now }()
+
+		fn("hero")
+		value, err := fn("hero")
+		assert.Nil(t, err)
+		assert.Equal(t, "Ada", value)
+		fn("villain")
+		_, err = fn("villain")
+		assert.Equal(t, ErrLookupNotFound, err)
+		assert.Equal(t, 2, calls)
+
+		current = current.Add(2 * time.Minute)
+		fail = true
+		_, err = fn("hero")
+		assert.Equal(t, failure, err)
+		fn("hero")
+		assert.Equal(t, 4, calls)
+	})
+	t.Run("evaluation errors", func(t *testing.T) {
+		var sb strings.Builder
+		e := NewEvaluation(&sb, WithLookup(MapLookup(map[string]string{"hero": "Ada"})))
+		assert.Nil(t, e.Evaluate(Node{Parts: []interface{}{Substitution{Key: "hero"}}}))
+		assert.Equal(t, "Ada", sb.String())
+		assert.Equal(t, ErrorNameNotFound{"villain"}, e.Evaluate(Node{Parts: []interface{}{Substitution{Key: "villain"}}}))
+
+		e = NewEvaluation(&sb, WithLookup(func(string) (string, error) { return "", failure }))
+		assert.Equal(t, ErrorLookup{"villain", failure}, e.Evaluate(Node{Parts: []interface{}{Substitution{Key: "villain"}}}))
+	})
+}
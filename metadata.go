@@ -0,0 +1,44 @@
+package tracerygo
+
+// This is extra information about a symbol or rule that isn't used to evaluate it, e.g. a description, an author's note, an ID or a deprecation flag
+type Metadata map[string]interface{}
+
+// This is a raw grammar along with the metadata of its symbols and rules, as read from the extended JSON form
+type RawDocument struct {
+	Grammar RawGrammar
+	// The metadata for each symbol written in the object form
+	Symbols map[string]Metadata
+	// The metadata for each rule of a symbol, in the same order as the rules; rules without any are nil
+	Rules map[string][]Metadata
+	// The tags of each rule of a symbol, in the same order as the rules; rules without any are nil
+	Tags map[string][][]string
+}
+
+// This parses the grammar of a document into nodes, carrying the metadata and tags onto each node
+func ParseDocument(d RawDocument) (Grammar, error) {
+	g, err := Parse(d.Grammar)
+	if err != nil {
+		return g, err
+	}
+	for name, nodes := range g {
+		rules, tags := d.Rules[name], d.Tags[name]
+		for i := range nodes {
+			nodes[i].Symbol = d.Symbols[name]
+			if i < len(rules) {
+				nodes[i].Meta = rules[i]
+			}
+			if i < len(tags) {
+				nodes[i].Tags = tags[i]
+			}
+		}
+	}
+	return g, nil
+}
+
+// This returns the metadata of a symbol; symbols without rules have nowhere to keep it, so it's nil for them
+func (g Grammar) Metadata(name string) Metadata {
+	if len(g[name]) == 0 {
+		return nil
+	}
+	return g[name][0].Symbol
+}
@@ -0,0 +1,98 @@
+package tracerygo
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetadata(t *testing.T) {
+	source := []byte(`{
+		"origin": "#greeting#, #animal#",
+		"greeting": ["hello", "hi"],
+		"animal": {
+			"description": "animals the hero meets",
+			"deprecated": true,
+			"rules": [
+				{"text": "mouse", "tags": ["small"], "author": "ada"},
+				"bear",
+				"wolf"
+			],
+			"rules_meta": [{"id": "a1", "author": "grace"}, null, {"id": "a3"}]
+		},
+		"sound": {"rules": "squeak", "rules_meta": [{"id": "s1"}]}
+	}`)
+
+	t.Run("document", func(t *testing.T) {
+		var d RawDocument
+		if !assert.Nil(t, json.Unmarshal(source, &d)) {
+			return
+		}
+		assert.Equal(t, RawGrammar{
+			"origin":   []string{"#greeting#, #animal#"},
+			"greeting": []string{"hello", "hi"},
+			"animal":   []string{"mouse", "bear", "wolf"},
+			"sound":    []string{"squeak"},
+		}, d.Grammar)
+		assert.Equal(t, map[string]Metadata{
+			"animal": {"description": "animals the hero meets", "deprecated": true},
+			"sound":  {},
+		}, d.Symbols)
+		assert.Equal(t, map[string][][]string{"animal": {{"small"}, nil, nil}}, d.Tags)
+		assert.Equal(t, map[string][]Metadata{
+			"animal": {{"id": "a1", "author": "ada"}, nil, {"id": "a3"}},
+			"sound":  {{"id": "s1"}},
+		}, d.Rules)
+
+		g, err := ParseDocument(d)
+		if !assert.Nil(t, err) {
+			return
+		}
+		assert.Equal(t, Metadata{"description": "animals the hero meets", "deprecated": true}, g.Metadata("animal"))
+		assert.Nil(t, g.Metadata("greeting"))
+		assert.Nil(t, g.Metadata("missing"))
+		assert.Equal(t, Metadata{"id": "a1", "author": "ada"}, g["animal"][0].Meta)
+		assert.Equal(t, []string{"small"}, g["animal"][0].Tags)
+		assert.Nil(t, g["animal"][1].Meta)
+		assert.Equal(t, Metadata{"id": "s1"}, g["sound"][0].Meta)
+
+		// the metadata is there at runtime on the node that's picked
+		e := NewEvaluation(nil, WithGrammar(g), WithRandom(&scriptedRandom{[]int{2}}))
+		n, err := e.EvaluateName("animal")
+		assert.Nil(t, err)
+		assert.Equal(t, Metadata{"id": "a3"}, n.Meta)
+		assert.Equal(t, true, n.Symbol["deprecated"])
+	})
+	t.Run("plain grammar still reads the object form", func(t *testing.T) {
+		var raw RawGrammar
+		untagged := []byte(`{
+			"origin": "#animal#",
+			"animal": {"description": "animals", "rules": [{"text": "mouse", "author": "ada"}, "bear"]}
+		}`)
+		if !assert.Nil(t, json.Unmarshal(untagged, &raw)) {
+			return
+		}
+		assert.Equal(t, []string{"mouse", "bear"}, raw["animal"])
+		result, err := raw.Evaluate("origin", 0, 0)
+		assert.Nil(t, err)
+		assert.NotEmpty(t, result)
+
+		// tags can't be kept in a plain grammar, so they aren't dropped quietly
+		err = json.Unmarshal(source, &raw)
+		assert.Equal(t, ErrorInField{"animal", ErrorExpectationFailed{"rules without tags (read tagged rules into a RawDocument and use ParseDocument)", "tags"}}, err)
+	})
+	t.Run("malformed", func(t *testing.T) {
+		var d RawDocument
+		for input, expected := range map[string]error{
+			`{"a": {"rules": 3}}`:                             ErrorInField{"a", ErrorExpectationFailed{"either an array of strings, a string, or an object with rules", "something else"}},
+			`{"a": {"rules": ["x"], "rules_meta": {}}}`:       ErrorInField{"a.rules_meta", ErrorExpectationFailed{"an array of objects", "something else"}},
+			`{"a": {"rules": ["x"], "rules_meta": [{}, {}]}}`: ErrorInField{"a.rules_meta", ErrorExpectationFailed{"no more than the 1 rules", "2"}},
+			`{"a": {"rules": ["x"], "rules_meta": ["note"]}}`: ErrorInField{"a.rules_meta[0]", ErrorExpectationFailed{"an object", "something else"}},
+			`{"a": {"rules": "x", "rules_meta": [{}, {}]}}`:   ErrorInField{"a.rules_meta", ErrorExpectationFailed{"no more than the 1 rules", "2"}},
+			`{"a": [{"text": "x", "tags": "small"}]}`:         ErrorInField{"a[0]", ErrorExpectationFailed{"'tags' to be an array of strings", "something else"}},
+		} {
+			assert.Equal(t, expected, json.Unmarshal([]byte(input), &d), input)
+		}
+	})
+}
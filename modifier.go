@@ -2,6 +2,8 @@ package tracerygo
 
 import (
 	"io"
+	"math"
+	"strconv"
 	"strings"
 )
 
@@ -20,17 +22,29 @@ var (
 		"ed":         modifierPastTenseIndex,
 		"a":          modifierIndefiniteArticleIndex,
 		"s":          modifierPluralizeIndex,
+		"ordinal":    modifierOrdinalIndex,
+		"words":      modifierWordsIndex,
+		"the":        modifierDefiniteArticleIndex,
+		"agree":      modifierAgreeIndex,
 	}
 	modifierCapitalizeIndex        = 1
 	modifierPastTenseIndex         = 2
 	modifierIndefiniteArticleIndex = 3
 	modifierPluralizeIndex         = 4
+	modifierOrdinalIndex           = 5
+	modifierWordsIndex             = 6
+	modifierDefiniteArticleIndex   = 7
+	modifierAgreeIndex             = 8
 	modifierLookup                 = []ModifierFunc{
 		nil,
 		ModifierCapitalize,
 		ModifierPastTense,
 		ModifierIndefiniteArticle,
 		ModifierPluralize,
+		ModifierOrdinal,
+		ModifierWords,
+		ModifierDefiniteArticle,
+		ModifierThirdPerson,
 	}
 )
 
@@ -46,6 +60,10 @@ func ModifierCapitalize(out io.Writer) Modifier {
 
 // This writes to the underlying stream; the first set of bytes that it gets it will try to capitalize the first letter of
 func (p *capitalizePipe) Write(b []byte) (int, error) {
+	if len(b) == 0 {
+		// nothing to capitalize yet; the first letter is still to come
+		return 0, nil
+	}
 	if p.done {
 		return p.out.Write(b)
 	} else {
@@ -98,6 +116,10 @@ func ModifierIndefiniteArticle(out io.Writer) Modifier {
 
 // This writes to the underlying stream; the first set of bytes are inspected and the suitable 'a' or 'an' is placed in front
 func (p *indefiniteArticlePipe) Write(b []byte) (int, error) {
+	if len(b) == 0 {
+		// there's no word to pick the article for yet
+		return 0, nil
+	}
 	if p.done {
 		return p.out.Write(b)
 	} else {
@@ -123,6 +145,37 @@ func (p *indefiniteArticlePipe) Finalize() error {
 	return nil
 }
 
+type definiteArticlePipe struct {
+	out  io.Writer
+	done bool
+}
+
+// This returns a modifier for prefixing the definite article to a noun
+func ModifierDefiniteArticle(out io.Writer) Modifier {
+	return &definiteArticlePipe{out: out, done: false}
+}
+
+// This writes to the underlying stream; 'the' is placed in front of the first set of bytes
+func (p *definiteArticlePipe) Write(b []byte) (int, error) {
+	if p.done {
+		return p.out.Write(b)
+	} else {
+		p.done = true
+		s := "the " + string(b)
+		l, err := p.out.Write([]byte(s))
+		if l == len(s) {
+			return len(b), err
+		} else {
+			return 0, ErrUnexpectedNumberOfBytesWritten
+		}
+	}
+}
+
+// This matches the interface but does nothing
+func (p *definiteArticlePipe) Finalize() error {
+	return nil
+}
+
 type pluralizePipe struct {
 	out io.Writer
 }
@@ -142,3 +195,133 @@ func (p *pluralizePipe) Finalize() error {
 	_, err := p.out.Write([]byte("s"))
 	return err
 }
+
+// this is a modifier that holds on to everything written to it, and writes a transformed version when it's flushed or finalized
+type bufferedPipe struct {
+	out       io.Writer
+	buffer    strings.Builder
+	transform func(string) string
+	done      bool
+}
+
+func (p *bufferedPipe) Write(b []byte) (int, error) {
+	if p.done {
+		return p.out.Write(b)
+	}
+	return p.buffer.Write(b)
+}
+
+func (p *bufferedPipe) Finalize() error {
+	return p.flush()
+}
+
+// this writes the transformed version once; anything written after that passes through, like a suffix does
+func (p *bufferedPipe) flush() error {
+	if p.done {
+		return nil
+	}
+	p.done = true
+	s := p.transform(p.buffer.String())
+	if s == "" {
+		// an empty write would look like the start of the text to modifiers further out
+		return nil
+	}
+	l, err := p.out.Write([]byte(s))
+	if err == nil && l != len(s) {
+		return ErrUnexpectedNumberOfBytesWritten
+	}
+	return err
+}
+
+// this flushes any buffered modifiers in a chain, starting with the one written to, so each sees the whole of what's inside it before the chain is finalized
+func flushModifiers(modifiers []Modifier) error {
+	for i := len(modifiers) - 1; i >= 0; i-- {
+		if p, ok := modifiers[i].(*bufferedPipe); ok {
+			if err := p.flush(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// This returns a modifier for turning a whole number into an ordinal, e.g. '3' into '3rd'; anything else is passed through
+func ModifierOrdinal(out io.Writer) Modifier {
+	return &bufferedPipe{out: out, transform: ordinal}
+}
+
+// This returns a modifier for writing a whole number out in words, e.g. '21' into 'twenty-one'; anything else is passed through
+func ModifierWords(out io.Writer) Modifier {
+	return &bufferedPipe{out: out, transform: numberWords}
+}
+
+func ordinal(s string) string {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return s
+	}
+	if n < 0 {
+		n = -n
+	}
+	switch {
+	case n%100 >= 11 && n%100 <= 13:
+		return s + "th"
+	case n%10 == 1:
+		return s + "st"
+	case n%10 == 2:
+		return s + "nd"
+	case n%10 == 3:
+		return s + "rd"
+	}
+	return s + "th"
+}
+
+var (
+	smallNumberWords = []string{
+		"zero", "one", "two", "three", "four", "five", "six", "seven", "eight", "nine",
+		"ten", "eleven", "twelve", "thirteen", "fourteen", "fifteen", "sixteen", "seventeen", "eighteen", "nineteen",
+	}
+	tensWords  = []string{"", "", "twenty", "thirty", "forty", "fifty", "sixty", "seventy", "eighty", "ninety"}
+	scaleWords = []struct {
+		value int
+		word  string
+	}{
+		{1000000000, "billion"},
+		{1000000, "million"},
+		{1000, "thousand"},
+		{100, "hundred"},
+	}
+)
+
+func numberWords(s string) string {
+	n, err := strconv.Atoi(s)
+	if err != nil || n == math.MinInt64 {
+		return s
+	}
+	if n < 0 {
+		return "minus " + wordsFor(-n)
+	}
+	return wordsFor(n)
+}
+
+func wordsFor(n int) string {
+	if n < 20 {
+		return smallNumberWords[n]
+	}
+	if n < 100 {
+		if n%10 == 0 {
+			return tensWords[n/10]
+		}
+		return tensWords[n/10] + "-" + smallNumberWords[n%10]
+	}
+	for _, scale := range scaleWords {
+		if n >= scale.value {
+			words := wordsFor(n/scale.value) + " " + scale.word
+			if n%scale.value != 0 {
+				words += " " + wordsFor(n%scale.value)
+			}
+			return words
+		}
+	}
+	return ""
+}
@@ -3,6 +3,7 @@ package tracerygo
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
 )
 
@@ -29,6 +30,36 @@ type tokenLookup struct {
 type variableDeclaration struct {
 	name      string
 	subtokens []interface{}
+	// when the declaration is a rule set (e.g. '[animal:cat,dog]') this holds the tokens of each rule instead of subtokens
+	rules [][]interface{}
+	// when the declaration is lazy (e.g. '[~adj:#mood#]') it is evaluated on each lookup
+	lazy bool
+}
+
+type conditionalToken struct {
+	key       string
+	value     string
+	negate    bool
+	then      []interface{}
+	otherwise []interface{}
+}
+
+func toVariable(decl variableDeclaration) (Variable, error) {
+	v := Variable{Key: decl.name, Lazy: decl.lazy}
+	if decl.rules == nil {
+		n, err := toNode(decl.subtokens)
+		v.Parts = n.Parts
+		return v, err
+	}
+	v.Rules = make([][]interface{}, len(decl.rules))
+	for i, r := range decl.rules {
+		n, err := toNode(r)
+		if err != nil {
+			return v, err
+		}
+		v.Rules[i] = n.Parts
+	}
+	return v, nil
 }
 
 func toNode(tokens []interface{}) (Node, error) {
@@ -43,14 +74,11 @@ func toNode(tokens []interface{}) (Node, error) {
 		case []variableDeclaration:
 			v := v.([]variableDeclaration)
 			for _, decl := range v {
-				subn, err := toNode(decl.subtokens)
+				variable, err := toVariable(decl)
 				if err != nil {
 					return n, err
 				}
-				n.Variables = append(n.Variables, Variable{
-					Key:   decl.name,
-					Parts: subn.Parts,
-				})
+				n.Variables = append(n.Variables, variable)
 			}
 		case tokenLookup:
 			t := v.(tokenLookup)
@@ -60,17 +88,23 @@ func toNode(tokens []interface{}) (Node, error) {
 				Key:       t.name,
 			}
 
+			var err error
+			if s.Key, s.Tags, err = nameTags(t.name); err != nil {
+				return n, err
+			}
+
+			if _, isCall, err := parseGenerator(s.Key); isCall && err != nil {
+				return n, err
+			}
+
 			if len(t.prefixes) > 0 {
 				s.Variables = make([]Variable, len(t.prefixes))
 				for i, p := range t.prefixes {
-					n, err := toNode(p.subtokens)
+					variable, err := toVariable(p)
 					if err != nil {
 						return n, err
 					}
-					s.Variables[i] = Variable{
-						Key:   p.name,
-						Parts: n.Parts,
-					}
+					s.Variables[i] = variable
 				}
 			}
 
@@ -78,6 +112,13 @@ func toNode(tokens []interface{}) (Node, error) {
 				s.Modifiers = make([]int, len(t.suffixes))
 				for i, m := range t.suffixes {
 					modifier, ok := modifierMap[m]
+					if character, agrees := agreementPrefix(m); !ok && agrees {
+						if s.Agreement == nil {
+							s.Agreement = make([]string, len(t.suffixes))
+						}
+						modifier, ok = modifierAgreeIndex, true
+						s.Agreement[i] = character
+					}
 					if !ok {
 						return n, ErrorUnsupportedModifier{m}
 					}
@@ -86,6 +127,23 @@ func toNode(tokens []interface{}) (Node, error) {
 			}
 
 			n.Parts = append(n.Parts, s)
+		case conditionalToken:
+			t := v.(conditionalToken)
+			then, err := toNode(t.then)
+			if err != nil {
+				return n, err
+			}
+			otherwise, err := toNode(t.otherwise)
+			if err != nil {
+				return n, err
+			}
+			n.Parts = append(n.Parts, Conditional{
+				Key:    t.key,
+				Value:  t.value,
+				Negate: t.negate,
+				Then:   then,
+				Else:   otherwise,
+			})
 		}
 	}
 	return n, nil
@@ -101,37 +159,58 @@ traversal:
 	for i := 0; i < len(input); i++ {
 		switch input[i] {
 		case '\\':
-			if input[i+1] == '#' {
+			if i+1 < len(input) && input[i+1] == '#' {
 				currentToken += "#"
 				i = i + 1
 				continue traversal
 			}
 		case '[':
-			// look ahead until we see the end, then break that string out to parse into a variable declaration
-			for k := i + 1; k < len(input); k++ {
-				switch input[k] {
-				case ']':
-					segments := strings.SplitN(input[i+1:k], ":", 2)
-					name := segments[0]
-					subparts, err := tokenize(segments[1])
-					if err != nil {
-						return nil, err
-					}
-					variableDeclarations = append(variableDeclarations, variableDeclaration{
-						name,
-						subparts,
-					})
-					i = k
-					continue traversal
+			// look ahead until we see the matching end, then break that string out to parse into a variable declaration or a conditional
+			k := closingBracket(input, i)
+			if k < 0 {
+				return parts, ErrorUnmatchedSymbol{i, "[", "]"}
+			}
+			body := input[i+1 : k]
+			if strings.HasPrefix(body, "if ") {
+				if inLookup >= 0 {
+					return nil, ErrorMalformedCondition{body, "conditions can't be used inside a substitution"}
 				}
+				cond, err := tokenizeCondition(body)
+				if err != nil {
+					return nil, err
+				}
+				if currentToken != "" {
+					parts = append(parts, currentToken)
+					currentToken = ""
+				}
+				parts = append(parts, cond)
+			} else {
+				decl, err := tokenizeDeclaration(body)
+				if err != nil {
+					return nil, err
+				}
+				variableDeclarations = append(variableDeclarations, decl)
 			}
-			return parts, ErrorUnmatchedSymbol{i, "[", "]"}
+			i = k
+			continue traversal
 		case '#':
 			if inLookup >= 0 {
 				inLookup = -1
 				tokenParts := strings.Split(currentToken, ".")
+				name := 1
+				if strings.HasPrefix(currentToken, "$") {
+					// a data path (e.g. '$user.firstName') runs until the first modifier; a field named like one is escaped (e.g. '$order.\s')
+					for name < len(tokenParts) {
+						if strings.HasPrefix(tokenParts[name], "\\") {
+							tokenParts[name] = tokenParts[name][1:]
+						} else if _, ok := modifierMap[tokenParts[name]]; ok {
+							break
+						}
+						name++
+					}
+				}
 
-				parts = append(parts, tokenLookup{variableDeclarations, tokenParts[0], tokenParts[1:]})
+				parts = append(parts, tokenLookup{variableDeclarations, strings.Join(tokenParts[:name], "."), tokenParts[name:]})
 				variableDeclarations = nil
 			} else {
 				inLookup = i
@@ -147,7 +226,7 @@ traversal:
 			// this is here to explicity skip the default behavior
 			continue traversal
 		}
-		currentToken += string(input[i])
+		currentToken += input[i : i+1]
 	}
 
 	if inLookup >= 0 {
@@ -164,33 +243,238 @@ traversal:
 	return parts, nil
 }
 
+// this finds the ']' that closes the '[' at start, skipping over nested pairs and escaped characters; it returns -1 if there isn't one
+func closingBracket(input string, start int) int {
+	depth := 0
+	for k := start; k < len(input); k++ {
+		switch input[k] {
+		case '\\':
+			k++
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				return k
+			}
+		}
+	}
+	return -1
+}
+
+// this parses the inside of a variable declaration, e.g. 'animal:cat,dog' or '~adj:#mood#'
+func tokenizeDeclaration(body string) (variableDeclaration, error) {
+	segments := strings.SplitN(body, ":", 2)
+	if len(segments) != 2 {
+		return variableDeclaration{}, ErrorExpectationFailed{"a declaration like [name:value]", fmt.Sprintf("[%s]", body)}
+	}
+	decl := variableDeclaration{name: segments[0]}
+	if strings.HasPrefix(decl.name, "~") {
+		decl.name = decl.name[1:]
+		decl.lazy = true
+	}
+	rules := splitRules(segments[1])
+	if len(rules) == 1 {
+		subparts, err := tokenize(rules[0])
+		if err != nil {
+			return decl, err
+		}
+		decl.subtokens = subparts
+		return decl, nil
+	}
+	for _, r := range rules {
+		subparts, err := tokenize(r)
+		if err != nil {
+			return decl, err
+		}
+		decl.rules = append(decl.rules, subparts)
+	}
+	return decl, nil
+}
+
+// this parses the inside of a conditional, e.g. 'if hero==robot:#mechVerb#|#verb#'
+func tokenizeCondition(body string) (conditionalToken, error) {
+	var cond conditionalToken
+	colon := strings.Index(body, ":")
+	if colon < 0 {
+		return cond, ErrorMalformedCondition{body, "expected a ':' between the condition and the rules"}
+	}
+	condition := strings.TrimSpace(body[len("if "):colon])
+	operator := strings.Index(condition, "==")
+	if negated := strings.Index(condition, "!="); negated >= 0 && (operator < 0 || negated < operator) {
+		operator = negated
+		cond.negate = true
+	}
+	if operator < 0 {
+		return cond, ErrorMalformedCondition{body, "expected the condition to compare with '==' or '!='"}
+	}
+	cond.key = strings.TrimSpace(condition[:operator])
+	cond.value = strings.TrimSpace(condition[operator+2:])
+	if cond.key == "" || strings.ContainsAny(cond.key, " #[]") {
+		return cond, ErrorMalformedCondition{body, "expected a name on the left of the comparison"}
+	}
+	if strings.ContainsAny(cond.value, "#[]") {
+		return cond, ErrorMalformedCondition{body, "expected plain text on the right of the comparison"}
+	}
+
+	branches := splitTopLevel(body[colon+1:], '|')
+	if len(branches) > 2 {
+		return cond, ErrorMalformedCondition{body, "expected at most two rules separated by '|'"}
+	}
+	var err error
+	if cond.then, err = tokenize(branches[0]); err != nil {
+		return cond, err
+	}
+	if len(branches) == 2 {
+		if cond.otherwise, err = tokenize(branches[1]); err != nil {
+			return cond, err
+		}
+	}
+	return cond, nil
+}
+
+// this splits the value of a variable declaration into rules on each comma, skipping escaped commas ('\,') and commas inside a substitution or nested declaration
+func splitRules(value string) []string {
+	return splitTopLevel(value, ',')
+}
+
+// this splits on a separator, skipping escaped separators and separators inside a substitution or nested brackets
+func splitTopLevel(value string, separator byte) []string {
+	var rules []string
+	var current strings.Builder
+	inLookup := false
+	depth := 0
+	for i := 0; i < len(value); i++ {
+		switch c := value[i]; {
+		case c == '\\' && i+1 < len(value):
+			// an escaped separator or backslash is kept as just the character
+			if value[i+1] != separator && value[i+1] != '\\' {
+				current.WriteByte(c)
+			}
+			current.WriteByte(value[i+1])
+			i++
+			continue
+		case c == '#':
+			inLookup = !inLookup
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+		case c == separator && !inLookup && depth == 0:
+			rules = append(rules, current.String())
+			current.Reset()
+			continue
+		}
+		current.WriteByte(value[i])
+	}
+	return append(rules, current.String())
+}
+
 func (g *RawGrammar) UnmarshalJSON(data []byte) error {
+	var d RawDocument
+	if err := json.Unmarshal(data, &d); err != nil {
+		return err
+	}
+	// a plain grammar has nowhere to keep tags, and dropping them would quietly let filtered rules through
+	names := make([]string, 0, len(d.Tags))
+	for name := range d.Tags {
+		names = append(names, name)
+	}
+	if len(names) != 0 {
+		sort.Strings(names)
+		return ErrorInField{names[0], ErrorExpectationFailed{"rules without tags (read tagged rules into a RawDocument and use ParseDocument)", "tags"}}
+	}
+	*g = d.Grammar
+	return nil
+}
+
+// This reads a grammar along with its metadata. Each key is a string, an array of rules, or an object with the rules under "rules", metadata for each rule under "rules_meta",
+// and anything else as metadata for the symbol. Rules in an array can be objects too, with the rule under "text" and anything other than "tags" as metadata for the rule
+func (d *RawDocument) UnmarshalJSON(data []byte) error {
 	intermediate := make(map[string]interface{})
-	local := make(RawGrammar)
+	local := RawDocument{Grammar: make(RawGrammar), Symbols: make(map[string]Metadata), Rules: make(map[string][]Metadata), Tags: make(map[string][][]string)}
 	if err := json.Unmarshal(data, &intermediate); err != nil {
 		return err
 	}
 
 	for k, intermediateValue := range intermediate {
+		var rulesMeta []interface{}
+		if v, ok := intermediateValue.(map[string]interface{}); ok {
+			symbol := make(Metadata)
+			for field, value := range v {
+				if field != "rules" && field != "rules_meta" {
+					symbol[field] = value
+				}
+			}
+			local.Symbols[k] = symbol
+			if v["rules_meta"] != nil {
+				if rulesMeta, ok = v["rules_meta"].([]interface{}); !ok {
+					return ErrorInField{k + ".rules_meta", ErrorExpectationFailed{"an array of objects", "something else"}}
+				}
+			}
+			intermediateValue = v["rules"]
+			if rule, ok := intermediateValue.(string); ok {
+				// a single rule is a list of one, so its metadata lines up the same way
+				intermediateValue = []interface{}{rule}
+			}
+		}
+
 		switch v := intermediateValue.(type) {
 		case string:
-			local[k] = []string{v}
+			local.Grammar[k] = []string{v}
 		case []interface{}:
 			temparr := make([]string, len(v))
+			meta := make([]Metadata, len(v))
+			tags := make([][]string, len(v))
+			found, tagged := false, false
 			for i, s := range v {
 				switch subv := s.(type) {
 				case string:
 					temparr[i] = subv
+				case map[string]interface{}:
+					rule, ruleTags, ruleMeta, err := taggedRule(subv)
+					if err != nil {
+						return ErrorInField{fmt.Sprintf("%s[%d]", k, i), err}
+					}
+					temparr[i] = rule
+					tags[i] = ruleTags
+					meta[i] = ruleMeta
+					tagged = tagged || ruleTags != nil
 				default:
-					return ErrorInField{fmt.Sprintf("%s[%d]", k, i), ErrorExpectationFailed{"a string", "something else"}}
+					return ErrorInField{fmt.Sprintf("%s[%d]", k, i), ErrorExpectationFailed{"a string or an object", "something else"}}
+				}
+				found = found || meta[i] != nil
+			}
+			local.Grammar[k] = temparr
+			if len(rulesMeta) > len(v) {
+				return ErrorInField{k + ".rules_meta", ErrorExpectationFailed{fmt.Sprintf("no more than the %d rules", len(v)), fmt.Sprintf("%d", len(rulesMeta))}}
+			}
+			for i, m := range rulesMeta {
+				object, ok := m.(map[string]interface{})
+				if m != nil && !ok {
+					return ErrorInField{fmt.Sprintf("%s.rules_meta[%d]", k, i), ErrorExpectationFailed{"an object", "something else"}}
+				}
+				for field, value := range object {
+					if meta[i] == nil {
+						meta[i] = make(Metadata)
+					}
+					if _, ok := meta[i][field]; !ok {
+						meta[i][field] = value
+					}
+					found = true
 				}
 			}
-			local[k] = temparr
+			if found {
+				local.Rules[k] = meta
+			}
+			if tagged {
+				local.Tags[k] = tags
+			}
 		default:
-			return ErrorInField{k, ErrorExpectationFailed{"either an array of strings or a string", "something else"}}
+			return ErrorInField{k, ErrorExpectationFailed{"either an array of strings, a string, or an object with rules", "something else"}}
 		}
 	}
-	*g = local
+	*d = local
 	return nil
 }
 
@@ -200,11 +484,7 @@ func Parse(g RawGrammar) (Grammar, error) {
 	for k, v := range g {
 		var nodes []Node
 		for i, raw := range v {
-			tokens, err := tokenize(raw)
-			if err != nil {
-				return final, ErrorInField{fmt.Sprintf("%s[%d]", k, i), err}
-			}
-			node, err := toNode(tokens)
+			node, err := parseRule(raw)
 			if err != nil {
 				return final, ErrorInField{fmt.Sprintf("%s[%d]", k, i), err}
 			}
@@ -215,3 +495,44 @@ func Parse(g RawGrammar) (Grammar, error) {
 	}
 	return final, nil
 }
+
+// this parses a single rule into a node
+func parseRule(raw string) (Node, error) {
+	tokens, err := tokenize(raw)
+	if err != nil {
+		return Node{}, err
+	}
+	return toNode(tokens)
+}
+
+// this turns the object form of a rule (e.g. '{"text": "mouse", "tags": ["small"]}') into the rule, its tags, and any other fields as metadata
+func taggedRule(object map[string]interface{}) (string, []string, Metadata, error) {
+	text, ok := object["text"].(string)
+	if !ok {
+		return "", nil, nil, ErrorExpectationFailed{"an object with a string 'text'", "something else"}
+	}
+	var meta Metadata
+	for field, value := range object {
+		if field != "text" && field != "tags" {
+			if meta == nil {
+				meta = make(Metadata)
+			}
+			meta[field] = value
+		}
+	}
+	raw, ok := object["tags"]
+	if !ok {
+		return text, nil, meta, nil
+	}
+	list, ok := raw.([]interface{})
+	if !ok {
+		return "", nil, nil, ErrorExpectationFailed{"'tags' to be an array of strings", "something else"}
+	}
+	tags := make([]string, len(list))
+	for i, t := range list {
+		if tags[i], ok = t.(string); !ok || strings.ContainsAny(tags[i], ",{}") {
+			return "", nil, nil, ErrorExpectationFailed{"'tags' to be an array of strings without ',', '{' or '}'", "something else"}
+		}
+	}
+	return text, tags, meta, nil
+}
@@ -39,7 +39,7 @@ func TestToNode(t *testing.T) {
 
 	n, err = toNode([]interface{}{
 		"hello ",
-		tokenLookup{[]variableDeclaration{{"myworld", []interface{}{"cool ", tokenLookup{nil, "world", []string{}}}}}, "myworld", nil},
+		tokenLookup{[]variableDeclaration{{name: "myworld", subtokens: []interface{}{"cool ", tokenLookup{nil, "world", []string{}}}}}, "myworld", nil},
 	})
 	assert.Nil(err)
 	assert.Equal(n, Node{
@@ -82,8 +82,8 @@ func TestToNode(t *testing.T) {
 	n, err = toNode([]interface{}{
 		[]variableDeclaration{
 			{
-				"neat",
-				[]interface{}{
+				name: "neat",
+				subtokens: []interface{}{
 					tokenLookup{nil, "test", nil},
 				},
 			},
@@ -94,8 +94,8 @@ func TestToNode(t *testing.T) {
 	assert.Equal(n, Node{
 		Variables: []Variable{
 			{
-				"neat",
-				[]interface{}{
+				Key: "neat",
+				Parts: []interface{}{
 					Substitution{
 						Variables: nil,
 						Modifiers: nil,
@@ -147,8 +147,8 @@ func TestTokenize(t *testing.T) {
 				tokenLookup{
 					[]variableDeclaration{
 						{
-							"myVar",
-							[]interface{}{tokenLookup{nil, "neat", []string{}}},
+							name:      "myVar",
+							subtokens: []interface{}{tokenLookup{nil, "neat", []string{}}},
 						},
 					},
 					"type",
@@ -165,8 +165,8 @@ func TestTokenize(t *testing.T) {
 			[]interface{}{
 				[]variableDeclaration{
 					{
-						"myVar",
-						[]interface{}{tokenLookup{nil, "neat", []string{}}},
+						name:      "myVar",
+						subtokens: []interface{}{tokenLookup{nil, "neat", []string{}}},
 					},
 				},
 				tokenLookup{
@@ -187,12 +187,12 @@ func TestTokenize(t *testing.T) {
 				tokenLookup{
 					[]variableDeclaration{
 						{
-							"mcArt",
-							[]interface{}{tokenLookup{nil, "artForm", []string{}}},
+							name:      "mcArt",
+							subtokens: []interface{}{tokenLookup{nil, "artForm", []string{}}},
 						},
 						{
-							"mcBoss",
-							[]interface{}{tokenLookup{nil, "boss", []string{}}},
+							name:      "mcBoss",
+							subtokens: []interface{}{tokenLookup{nil, "boss", []string{}}},
 						},
 					},
 					"artPlot",
@@ -202,4 +202,127 @@ func TestTokenize(t *testing.T) {
 			parts,
 		)
 	}
+
+	parts, err = tokenize("[animal:cat,big\\, old dog,#bird#]#animal#")
+	if assert.Nil(err) {
+		assert.Equal(
+			[]interface{}{
+				[]variableDeclaration{
+					{
+						name: "animal",
+						rules: [][]interface{}{
+							{"cat"},
+							{"big, old dog"},
+							{tokenLookup{nil, "bird", []string{}}},
+						},
+					},
+				},
+				tokenLookup{nil, "animal", []string{}},
+			},
+			parts,
+		)
+	}
+
+	parts, err = tokenize("[~adj:#mood#]#adj#")
+	if assert.Nil(err) {
+		assert.Equal(
+			[]interface{}{
+				[]variableDeclaration{
+					{
+						name:      "adj",
+						subtokens: []interface{}{tokenLookup{nil, "mood", []string{}}},
+						lazy:      true,
+					},
+				},
+				tokenLookup{nil, "adj", []string{}},
+			},
+			parts,
+		)
+	}
+
+	parts, err = tokenize("¿qué #tal#?")
+	if assert.Nil(err) {
+		assert.Equal([]interface{}{"¿qué ", tokenLookup{nil, "tal", []string{}}, "?"}, parts)
+	}
+
+	parts, err = tokenize("#$user.pets.0.name.capitalize.s#")
+	if assert.Nil(err) {
+		assert.Equal([]interface{}{tokenLookup{nil, "$user.pets.0.name", []string{"capitalize", "s"}}}, parts)
+	}
+
+	parts, err = tokenize("#$order.\\s.\\a.s#")
+	if assert.Nil(err) {
+		assert.Equal([]interface{}{tokenLookup{nil, "$order.s.a", []string{"s"}}}, parts)
+	}
+}
+
+func TestSplitRules(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal([]string{"cat"}, splitRules("cat"))
+	assert.Equal([]string{"cat", "dog", ""}, splitRules("cat,dog,"))
+	assert.Equal([]string{"a, b", "c"}, splitRules("a\\, b,c"))
+	assert.Equal([]string{"#a,b#", "\\#c"}, splitRules("#a,b#,\\#c"))
+	assert.Equal([]string{"#[x:1,2]y#", "z"}, splitRules("#[x:1,2]y#,z"))
+	assert.Equal([]string{"x\\", "y"}, splitRules("x\\\\,y"))
+
+	// a backslash at the end of a rule is just a backslash
+	for _, rule := range []string{"[a:x\\\\,y]#a#", "x\\", "[a:x\\\\]#a#"} {
+		assert.NotPanics(func() {
+			_, err := Parse(RawGrammar{"o": {rule}})
+			assert.Nil(err, rule)
+		}, rule)
+	}
+}
+
+func TestTokenizeCondition(t *testing.T) {
+	assert := assert.New(t)
+
+	parts, err := tokenize("it [if hero==robot:#mechVerb#|#verb# slowly] away")
+	if assert.Nil(err) {
+		assert.Equal(
+			[]interface{}{
+				"it ",
+				conditionalToken{
+					key:       "hero",
+					value:     "robot",
+					then:      []interface{}{tokenLookup{nil, "mechVerb", []string{}}},
+					otherwise: []interface{}{tokenLookup{nil, "verb", []string{}}, " slowly"},
+				},
+				" away",
+			},
+			parts,
+		)
+	}
+
+	parts, err = tokenize("[if mood != :#[x:#y#]z#]")
+	if assert.Nil(err) {
+		assert.Equal(
+			[]interface{}{
+				conditionalToken{
+					key:    "mood",
+					negate: true,
+					then: []interface{}{tokenLookup{
+						[]variableDeclaration{{name: "x", subtokens: []interface{}{tokenLookup{nil, "y", []string{}}}}},
+						"z",
+						[]string{},
+					}},
+				},
+			},
+			parts,
+		)
+	}
+
+	for input, reason := range map[string]string{
+		"[if hero robot:a]":      "expected the condition to compare with '==' or '!='",
+		"[if hero==robot]":       "expected a ':' between the condition and the rules",
+		"[if ==robot:a]":         "expected a name on the left of the comparison",
+		"[if hero==#x#:a]":       "expected plain text on the right of the comparison",
+		"[if hero==robot:a|b|c]": "expected at most two rules separated by '|'",
+		"#[if hero==robot:a]b#":  "conditions can't be used inside a substitution",
+	} {
+		_, err := tokenize(input)
+		if assert.IsType(ErrorMalformedCondition{}, err, input) {
+			assert.Equal(reason, err.(ErrorMalformedCondition).Reason)
+		}
+	}
 }
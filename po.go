@@ -0,0 +1,231 @@
+package tracerygo
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// This writes every rule of the default grammar as a gettext PO file for translating into tag. Each rule is its own entry, with the rule's place (e.g. 'origin[0]') as the context,
+// and the translation already in the bundle, if any, as the msgstr
+func (b *Bundle) WritePO(w io.Writer, tag string) error {
+	tag = canonicalTag(tag)
+	source := b.Grammars[b.Default]
+	translated := b.Grammars[tag]
+	names := make([]string, 0, len(source))
+	for name := range source {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "msgid \"\"\nmsgstr %s\n", poQuote("Language: "+tag+"\nContent-Type: text/plain; charset=UTF-8\n"))
+	for _, name := range names {
+		for i, rule := range source[name] {
+			translation := ""
+			if i < len(translated[name]) {
+				translation = translated[name][i]
+			}
+			// a rule that's the same as what it falls back to is one ReadPO filled in, rather than a translation
+			if fallback, ok := b.fallbackRule(tag, name, i); ok && fallback == translation {
+				translation = ""
+			}
+			context := fmt.Sprintf("%s[%d]", name, i)
+			fmt.Fprintf(bw, "\n#: %s\nmsgctxt %s\nmsgid %s\nmsgstr %s\n", context, poQuote(context), poQuote(rule), poQuote(translation))
+		}
+	}
+	return bw.Flush()
+}
+
+// This reads the translations for tag from a gettext PO file written by WritePO. Each symbol with a translated rule replaces the tag's rules for that symbol,
+// keeping every rule of the default grammar in its place; rules without a translation are filled in from what the tag falls back to, so a later WritePO lines up.
+// Untranslated and fuzzy entries are skipped, so those rules fall back as before, and so are entries for rules no longer in the default grammar
+func (b *Bundle) ReadPO(r io.Reader, tag string) error {
+	entries, err := readPOEntries(r)
+	if err != nil {
+		return err
+	}
+	tag = canonicalTag(tag)
+	source := b.Grammars[b.Default]
+	rules := make(map[string]map[int]string)
+	for _, entry := range entries {
+		if entry.fuzzy || entry.msgstr == "" || entry.msgctxt == "" {
+			continue
+		}
+		open := strings.LastIndex(entry.msgctxt, "[")
+		i, err := strconv.Atoi(strings.TrimSuffix(entry.msgctxt[open+1:], "]"))
+		if open < 0 || !strings.HasSuffix(entry.msgctxt, "]") || err != nil {
+			return ErrorMalformedPO{entry.line, fmt.Sprintf("expected a context like 'origin[0]', got '%s'", entry.msgctxt)}
+		}
+		name := entry.msgctxt[:open]
+		if i < 0 || i >= len(source[name]) {
+			continue
+		}
+		if rules[name] == nil {
+			rules[name] = make(map[int]string)
+		}
+		rules[name][i] = entry.msgstr
+	}
+
+	g := b.Grammars[tag]
+	if g == nil {
+		g = make(RawGrammar)
+	}
+	for name, translated := range rules {
+		g[name] = make([]string, len(source[name]))
+		for i := range g[name] {
+			if rule, ok := translated[i]; ok {
+				g[name][i] = rule
+			} else {
+				g[name][i], _ = b.fallbackRule(tag, name, i)
+			}
+		}
+	}
+	b.Add(tag, g)
+	return nil
+}
+
+// this is the rule a tag gets in place of its own for a symbol's rule, from the first of its fallbacks that has it
+func (b *Bundle) fallbackRule(tag string, name string, i int) (string, bool) {
+	for _, t := range b.Fallbacks(tag)[1:] {
+		if rules, ok := b.Grammars[t][name]; ok {
+			if i < len(rules) {
+				return rules[i], true
+			}
+			return "", false
+		}
+	}
+	return "", false
+}
+
+type poEntry struct {
+	line    int
+	fuzzy   bool
+	msgctxt string
+	msgid   string
+	msgstr  string
+}
+
+// this reads the entries of a PO file; an entry ends after its msgstr, and strings can continue over several quoted lines
+func readPOEntries(r io.Reader) ([]poEntry, error) {
+	var entries []poEntry
+	var current poEntry
+	var field *string
+	complete := false
+	finish := func() {
+		if complete {
+			entries = append(entries, current)
+		}
+		current, field, complete = poEntry{}, nil, false
+	}
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		switch {
+		case text == "":
+			finish()
+			continue
+		case strings.HasPrefix(text, "#"):
+			if complete {
+				finish()
+			}
+			if strings.HasPrefix(text, "#,") && strings.Contains(text, "fuzzy") {
+				current.fuzzy = true
+			}
+			continue
+		case strings.HasPrefix(text, "\""):
+			if field == nil {
+				return nil, ErrorMalformedPO{line, "expected a keyword before a continued string"}
+			}
+			s, err := poUnquote(text)
+			if err != nil {
+				return nil, ErrorMalformedPO{line, err.Error()}
+			}
+			*field += s
+			continue
+		}
+
+		keyword := strings.SplitN(text, " ", 2)
+		if len(keyword) != 2 {
+			return nil, ErrorMalformedPO{line, fmt.Sprintf("expected a keyword and a string, got '%s'", text)}
+		}
+		if complete && (keyword[0] == "msgctxt" || keyword[0] == "msgid") {
+			finish()
+		}
+		if current.line == 0 {
+			current.line = line
+		}
+		switch keyword[0] {
+		case "msgctxt":
+			field = &current.msgctxt
+		case "msgid":
+			field = &current.msgid
+		case "msgstr":
+			field = &current.msgstr
+			complete = true
+		default:
+			// plural forms and previous strings aren't used
+			field = new(string)
+		}
+		s, err := poUnquote(strings.TrimSpace(keyword[1]))
+		if err != nil {
+			return nil, ErrorMalformedPO{line, err.Error()}
+		}
+		*field = s
+	}
+	finish()
+	return entries, scanner.Err()
+}
+
+// this quotes a string for a PO file, splitting it over several lines at line breaks
+func poQuote(s string) string {
+	escape := strings.NewReplacer("\\", "\\\\", "\"", "\\\"", "\t", "\\t", "\n", "\\n")
+	lines := strings.SplitAfter(s, "\n")
+	if len(lines) > 1 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) == 1 {
+		return "\"" + escape.Replace(s) + "\""
+	}
+	quoted := "\"\""
+	for _, l := range lines {
+		quoted += "\n\"" + escape.Replace(l) + "\""
+	}
+	return quoted
+}
+
+// this reads a quoted PO string
+func poUnquote(s string) (string, error) {
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return "", fmt.Errorf("expected a quoted string, got '%s'", s)
+	}
+	var sb strings.Builder
+	for i := 1; i < len(s)-1; i++ {
+		if s[i] != '\\' {
+			if s[i] == '"' {
+				return "", fmt.Errorf("unescaped quote in '%s'", s)
+			}
+			sb.WriteByte(s[i])
+			continue
+		}
+		i++
+		if i == len(s)-1 {
+			return "", fmt.Errorf("unfinished escape in '%s'", s)
+		}
+		switch s[i] {
+		case 'n':
+			sb.WriteByte('\n')
+		case 't':
+			sb.WriteByte('\t')
+		case '"', '\\':
+			sb.WriteByte(s[i])
+		default:
+			return "", fmt.Errorf("unknown escape '\\%c' in '%s'", s[i], s)
+		}
+	}
+	return sb.String(), nil
+}
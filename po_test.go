@@ -0,0 +1,119 @@
+package tracerygo
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPO(t *testing.T) {
+	b := NewBundle("en")
+	b.Add("en", RawGrammar{
+		"origin": []string{"#greeting#, \"#name#\"", "line one\nline two"},
+		"name":   []string{"world"},
+	})
+	b.Add("fr", RawGrammar{
+		"origin": []string{"#greeting#, « #name# »"},
+	})
+
+	t.Run("write", func(t *testing.T) {
+		var sb strings.Builder
+		assert.Nil(t, b.WritePO(&sb, "fr"))
+		assert.Equal(t, `msgid ""
+msgstr ""
+"Language: fr\n"
+"Content-Type: text/plain; charset=UTF-8\n"
+
+#: name[0]
+msgctxt "name[0]"
+msgid "world"
+msgstr ""
+
+#: origin[0]
+msgctxt "origin[0]"
+msgid "#greeting#, \"#name#\""
+msgstr "#greeting#, « #name# »"
+
+#: origin[1]
+msgctxt "origin[1]"
+msgid ""
+"line one\n"
+"line two"
+msgstr ""
+`, sb.String())
+	})
+	t.Run("round trip", func(t *testing.T) {
+		var sb strings.Builder
+		assert.Nil(t, b.WritePO(&sb, "fr"))
+		translated := strings.Replace(sb.String(), "msgid \"world\"\nmsgstr \"\"", "msgid \"world\"\nmsgstr \"le monde\"", 1)
+		translated = strings.Replace(translated, "\"line two\"\nmsgstr \"\"", "\"line two\"\nmsgstr \"\"\n\"ligne un\\n\"\n\"ligne deux\"", 1)
+
+		imported := NewBundle("en")
+		imported.Add("en", b.Grammars["en"])
+		assert.Nil(t, imported.ReadPO(strings.NewReader(translated), "fr"))
+		assert.Equal(t, RawGrammar{
+			"origin": []string{"#greeting#, « #name# »", "ligne un\nligne deux"},
+			"name":   []string{"le monde"},
+		}, imported.Grammars["fr"])
+	})
+	t.Run("untranslated and fuzzy entries fall back", func(t *testing.T) {
+		imported := NewBundle("en")
+		imported.Add("en", RawGrammar{"origin": []string{"hello", "hi"}, "greeting": []string{"hey"}})
+		imported.Add("es", RawGrammar{"name": []string{"mundo"}})
+		assert.Nil(t, imported.ReadPO(strings.NewReader(`
+# a translator's note
+#, fuzzy
+msgctxt "origin[0]"
+msgid "hello"
+msgstr "hola"
+
+msgctxt "origin[1]"
+msgid "hi"
+msgstr ""
+msgctxt "greeting[0]"
+msgid "hey"
+msgstr "oye"
+
+msgctxt "greeting[3]"
+msgid "yo"
+msgstr "obsoleto"
+`), "es"))
+		assert.Equal(t, RawGrammar{"name": []string{"mundo"}, "greeting": []string{"oye"}}, imported.Grammars["es"])
+	})
+	t.Run("partial translations keep their places", func(t *testing.T) {
+		partial := NewBundle("en")
+		partial.Add("en", RawGrammar{"origin": []string{"one", "two", "three"}})
+		partial.Add("fr", RawGrammar{"origin": []string{"un (fr)", "deux (fr)", "trois (fr)"}})
+		assert.Nil(t, partial.ReadPO(strings.NewReader(`
+msgctxt "origin[0]"
+msgid "one"
+msgstr "un"
+
+msgctxt "origin[1]"
+msgid "two"
+msgstr ""
+
+msgctxt "origin[2]"
+msgid "three"
+msgstr "trois"
+`), "fr-CA"))
+		assert.Equal(t, RawGrammar{"origin": []string{"un", "deux (fr)", "trois"}}, partial.Grammars["fr-CA"])
+
+		var sb strings.Builder
+		assert.Nil(t, partial.WritePO(&sb, "fr-CA"))
+		assert.Contains(t, sb.String(), "msgid \"one\"\nmsgstr \"un\"\n")
+		assert.Contains(t, sb.String(), "msgid \"two\"\nmsgstr \"\"\n")
+		assert.Contains(t, sb.String(), "msgid \"three\"\nmsgstr \"trois\"\n")
+	})
+	t.Run("malformed", func(t *testing.T) {
+		for input, expected := range map[string]ErrorMalformedPO{
+			"msgctxt \"origin\"\nmsgid \"a\"\nmsgstr \"b\"": {1, "expected a context like 'origin[0]', got 'origin'"},
+			"\"stray\"":                    {1, "expected a keyword before a continued string"},
+			"msgid \"a\"\nmsgstr unquoted": {2, "expected a quoted string, got 'unquoted'"},
+			"msgid \"a\\q\"":               {1, "unknown escape '\\q' in '\"a\\q\"'"},
+		} {
+			assert.Equal(t, expected, NewBundle("en").ReadPO(strings.NewReader(input), "fr"), input)
+		}
+	})
+}
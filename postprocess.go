@@ -0,0 +1,212 @@
+package tracerygo
+
+import (
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// This builds a stage of post-processing around the stream it writes to. Stages see the output as it's written, and write anything they've held on to when finalized
+type PostProcessor func(out io.Writer) Modifier
+
+// This post-processes the output of an evaluation. When there's more than one, the first given sees the output first.
+// Stages may hold on to output, so Flush needs to be called once the evaluation is done
+func WithPostProcessor(processors ...PostProcessor) EvaluationModifier {
+	return func(e *Evaluation) {
+		e.processors = append(e.processors, processors...)
+	}
+}
+
+// This writes out anything the post-processing stages are holding on to, and checks a replayed tape was used up; it should be called once, after the last Evaluate
+func (e *Evaluation) Flush() error {
+	for i := len(e.stages) - 1; i >= 0; i-- {
+		if err := e.stages[i].Finalize(); err != nil {
+			return err
+		}
+	}
+	if e.replayed != nil {
+		return e.replayed()
+	}
+	return nil
+}
+
+// this is a post-processing stage that looks at a byte at a time; step writes what should be written for each byte, and flush writes anything held on to at the end
+type byteStage struct {
+	out    io.Writer
+	buffer []byte
+	step   func(buffer []byte, c byte) []byte
+	flush  func(buffer []byte) []byte
+}
+
+func (s *byteStage) Write(b []byte) (int, error) {
+	s.buffer = s.buffer[:0]
+	for _, c := range b {
+		s.buffer = s.step(s.buffer, c)
+	}
+	if err := s.write(s.buffer); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+func (s *byteStage) Finalize() error {
+	if s.flush == nil {
+		return nil
+	}
+	return s.write(s.flush(s.buffer[:0]))
+}
+
+func (s *byteStage) write(b []byte) error {
+	if len(b) == 0 {
+		return nil
+	}
+	l, err := s.out.Write(b)
+	if err == nil && l != len(b) {
+		return ErrUnexpectedNumberOfBytesWritten
+	}
+	return err
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r'
+}
+
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// This collapses runs of spaces and tabs into a single space, and drops them at the start and end of lines; line breaks are kept
+func CollapseWhitespace() PostProcessor {
+	return func(out io.Writer) Modifier {
+		space, lineStart := false, true
+		return &byteStage{
+			out: out,
+			step: func(buffer []byte, c byte) []byte {
+				switch {
+				case isSpace(c):
+					space = !lineStart
+					return buffer
+				case c == '\n':
+					space, lineStart = false, true
+				default:
+					if space {
+						buffer = append(buffer, ' ')
+					}
+					space, lineStart = false, false
+				}
+				return append(buffer, c)
+			},
+		}
+	}
+}
+
+// This removes spaces before punctuation (e.g. 'hello , world !' becomes 'hello, world!') and adds a missing space between ',', ';' or ':' and a following letter
+func FixPunctuationSpacing() PostProcessor {
+	return func(out io.Writer) Modifier {
+		var spaces []byte
+		separator := false
+		return &byteStage{
+			out: out,
+			step: func(buffer []byte, c byte) []byte {
+				if isSpace(c) {
+					spaces = append(spaces, c)
+					separator = false
+					return buffer
+				}
+				if !strings.ContainsRune(",.;:!?", rune(c)) {
+					buffer = append(buffer, spaces...)
+					if separator && isLetter(c) {
+						buffer = append(buffer, ' ')
+					}
+				}
+				spaces = spaces[:0]
+				separator = c == ',' || c == ';' || c == ':'
+				return append(buffer, c)
+			},
+			flush: func(buffer []byte) []byte {
+				return append(buffer, spaces...)
+			},
+		}
+	}
+}
+
+// This capitalizes the first letter of the output, and the first letter after '.', '!' or '?' and a space; quotes and brackets around the space are skipped over.
+// Abbreviations like 'e.g.' can't be told apart from the end of a sentence
+func SentenceCase() PostProcessor {
+	return func(out io.Writer) Modifier {
+		const (
+			inSentence = iota
+			afterTerminal
+			sentenceStart
+		)
+		state := sentenceStart
+		return &byteStage{
+			out: out,
+			step: func(buffer []byte, c byte) []byte {
+				switch {
+				case c == '.' || c == '!' || c == '?':
+					if state != sentenceStart {
+						state = afterTerminal
+					}
+				case state == afterTerminal && strings.ContainsRune("\"')]", rune(c)):
+				case isSpace(c) || c == '\n':
+					if state == afterTerminal {
+						state = sentenceStart
+					}
+				case state == sentenceStart && strings.ContainsRune("\"'([", rune(c)):
+				case state == sentenceStart && c >= 'a' && c <= 'z':
+					c -= 'a' - 'A'
+					state = inSentence
+				default:
+					state = inSentence
+				}
+				return append(buffer, c)
+			},
+		}
+	}
+}
+
+// This wraps lines at the last space before they'd go past the given number of columns; words longer than a line are left on a line of their own
+func WordWrap(columns int) PostProcessor {
+	return func(out io.Writer) Modifier {
+		var word, spaces []byte
+		line := 0
+		// this writes out the word held on to, on a new line if it doesn't fit on this one
+		place := func(buffer []byte) []byte {
+			if len(word) == 0 {
+				return buffer
+			}
+			length := utf8.RuneCount(word)
+			if line > 0 && line+len(spaces)+length > columns {
+				buffer = append(buffer, '\n')
+				line = 0
+			} else {
+				buffer = append(buffer, spaces...)
+				line += len(spaces)
+			}
+			buffer = append(buffer, word...)
+			line += length
+			word, spaces = word[:0], spaces[:0]
+			return buffer
+		}
+		return &byteStage{
+			out: out,
+			step: func(buffer []byte, c byte) []byte {
+				switch {
+				case isSpace(c):
+					buffer = place(buffer)
+					if line > 0 {
+						spaces = append(spaces, c)
+					}
+				case c == '\n':
+					buffer = append(place(buffer), c)
+					line, spaces = 0, spaces[:0]
+				default:
+					word = append(word, c)
+				}
+				return buffer
+			},
+			flush: place,
+		}
+	}
+}
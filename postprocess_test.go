@@ -0,0 +1,57 @@
+package tracerygo
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// this writes each chunk through the processor separately, so state carried between writes is tested too
+func process(t *testing.T, p PostProcessor, chunks ...string) string {
+	var sb strings.Builder
+	stage := p(&sb)
+	for _, c := range chunks {
+		n, err := stage.Write([]byte(c))
+		assert.Nil(t, err)
+		assert.Equal(t, len(c), n)
+	}
+	assert.Nil(t, stage.Finalize())
+	return sb.String()
+}
+
+func TestPostProcessors(t *testing.T) {
+	t.Run("collapse whitespace", func(t *testing.T) {
+		assert.Equal(t, "a b c\nd e", process(t, CollapseWhitespace(), "  a  ", " b\t", "c  \n  d ", "  e  "))
+	})
+	t.Run("punctuation spacing", func(t *testing.T) {
+		assert.Equal(t, "hello, world! 1,000 ok; go: now...", process(t, FixPunctuationSpacing(), "hello ", ",world ", "! 1,000 ok ;go :now ", ". . ."))
+		assert.Equal(t, "trailing ", process(t, FixPunctuationSpacing(), "trailing "))
+	})
+	t.Run("sentence case", func(t *testing.T) {
+		assert.Equal(t, "One. Two? \"Three!\" (Four) 3.5 six.\nSeven", process(t, SentenceCase(), "one. two?", " \"three!\" (", "four) 3.5 six.\nseven"))
+	})
+	t.Run("word wrap", func(t *testing.T) {
+		assert.Equal(t, "the quick\nbrown fox\njumps over\nthe lazy\ndog", process(t, WordWrap(10), "the quick brown fo", "x jumps over the lazy dog"))
+		assert.Equal(t, "a\nincomprehensibilities\nb\nc d", process(t, WordWrap(5), "a incomprehensibilities b\nc d"))
+		assert.Equal(t, "déjà vu\nagain", process(t, WordWrap(7), "déjà vu again"))
+	})
+	t.Run("pipeline", func(t *testing.T) {
+		g, err := Parse(RawGrammar{
+			"origin":   []string{"#greeting# #empty# , #name# . #greeting# #name# !"},
+			"greeting": []string{"hello"},
+			"name":     []string{"world"},
+			"empty":    []string{""},
+		})
+		if !assert.Nil(t, err) {
+			return
+		}
+		var sb strings.Builder
+		e := NewEvaluation(&sb, WithGrammar(g), WithPostProcessor(CollapseWhitespace(), FixPunctuationSpacing(), SentenceCase()), WithPostProcessor(WordWrap(14)))
+		n, err := e.EvaluateName("origin")
+		assert.Nil(t, err)
+		assert.Nil(t, e.Evaluate(n))
+		assert.Nil(t, e.Flush())
+		assert.Equal(t, "Hello, world.\nHello world!", sb.String())
+	})
+}
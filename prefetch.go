@@ -0,0 +1,122 @@
+package tracerygo
+
+import "sync"
+
+// this is what resolving a name ahead of time came back with; errors are kept rather than returned, since the name may never actually be reached
+type prefetchResult struct {
+	nodes []Node
+	err   error
+}
+
+// This resolves the external names of each evaluation ahead of time, asking the provider and lookup function concurrently with at most workers at a time
+func WithPrefetch(workers int) EvaluationModifier {
+	return func(e *Evaluation) {
+		e.workers = workers
+	}
+}
+
+// This resolves every name a node can reach that isn't in the grammar, asking the provider and lookup function concurrently with at most workers at a time.
+// Evaluation then uses the results rather than asking again. Rules returned by a provider are searched for more names in turn
+func (e *Evaluation) Prefetch(n Node, workers int) error {
+	if workers < 1 {
+		workers = 1
+	}
+	if e.prefetched == nil {
+		e.prefetched = make(map[string]prefetchResult)
+	}
+	visited := make(map[string]bool)
+	pending := e.externalNames([]Node{n}, visited)
+	for len(pending) > 0 {
+		if err := e.ctx.Err(); err != nil {
+			return err
+		}
+		results := make([]prefetchResult, len(pending))
+		jobs := make(chan int)
+		var wg sync.WaitGroup
+		for w := 0; w < workers && w < len(pending); w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range jobs {
+					results[i].nodes, results[i].err = e.resolve(pending[i])
+				}
+			}()
+		}
+		for i := range pending {
+			jobs <- i
+		}
+		close(jobs)
+		wg.Wait()
+
+		var found []Node
+		for i, name := range pending {
+			e.prefetched[name] = results[i]
+			found = append(found, results[i].nodes...)
+		}
+		pending = e.externalNames(found, visited)
+	}
+	return e.ctx.Err()
+}
+
+// this walks the grammar from the nodes, returning the names it reaches that aren't in the grammar or already fetched.
+// Names declared as variables anywhere along the way are bound while evaluating, so they're left out
+func (e *Evaluation) externalNames(nodes []Node, visited map[string]bool) []string {
+	var queue []string
+	declared := make(map[string]bool)
+	for _, n := range nodes {
+		queue = append(queue, profileNode(n.Variables, n.Parts, nil).expansions...)
+		declaredNames(n.Variables, n.Parts, declared)
+	}
+	var reached []string
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		if visited[name] {
+			continue
+		}
+		visited[name] = true
+		rules, ok := e.Grammar[name]
+		if !ok || len(rules) == 0 {
+			if _, done := e.prefetched[name]; !done {
+				reached = append(reached, name)
+			}
+			continue
+		}
+		for _, r := range rules {
+			queue = append(queue, profileNode(r.Variables, r.Parts, nil).expansions...)
+			declaredNames(r.Variables, r.Parts, declared)
+		}
+	}
+	var external []string
+	for _, name := range reached {
+		if !declared[name] {
+			external = append(external, name)
+		}
+	}
+	return external
+}
+
+// this collects the names declared by variables in a node, including the names a pronoun declaration binds
+func declaredNames(variables []Variable, parts []interface{}, declared map[string]bool) {
+	for _, v := range variables {
+		declared[v.Key] = true
+		if prefix, ok := pronounPrefix(v.Key); ok {
+			for _, name := range pronounNames {
+				declared[pronounName(prefix, name)] = true
+			}
+		}
+		declaredNames(nil, v.Parts, declared)
+		for _, r := range v.Rules {
+			declaredNames(nil, r, declared)
+		}
+	}
+	for _, abstract := range parts {
+		switch p := abstract.(type) {
+		case Substitution:
+			declaredNames(p.Variables, nil, declared)
+		case Conditional:
+			declaredNames(p.Then.Variables, p.Then.Parts, declared)
+			declaredNames(p.Else.Variables, p.Else.Parts, declared)
+		}
+	}
+}
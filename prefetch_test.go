@@ -0,0 +1,83 @@
+package tracerygo
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrefetch(t *testing.T) {
+	g, err := Parse(RawGrammar{
+		"origin": []string{"#greeting#, #name#! #[hero:#name#]story#"},
+		"story":  []string{"#hero# meets #villain#", "#hero# rests"},
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+	origin := g["origin"][0]
+
+	var mu sync.Mutex
+	var asked []string
+	var inFlight, most int32
+	provider := ProviderFunc(func(ctx context.Context, name string) ([]string, error) {
+		current := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		mu.Lock()
+		asked = append(asked, name)
+		if current > most {
+			most = current
+		}
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		switch name {
+		case "greeting":
+			return []string{"hello #title#"}, nil
+		case "title":
+			return []string{"friend"}, nil
+		case "name":
+			return []string{"Ada"}, nil
+		case "villain":
+			return []string{"Moriarty"}, nil
+		}
+		return nil, ErrLookupNotFound
+	})
+
+	t.Run("fetches everything reachable once", func(t *testing.T) {
+		asked, most = nil, 0
+		var sb strings.Builder
+		e := NewEvaluation(&sb, WithGrammar(g), WithProvider(provider), WithPrefetch(2))
+		assert.Nil(t, e.Evaluate(origin))
+		assert.True(t, strings.HasPrefix(sb.String(), "hello friend, Ada! Ada "), sb.String())
+		assert.ElementsMatch(t, []string{"greeting", "name", "villain", "title"}, asked)
+		assert.Equal(t, int32(2), most)
+	})
+	t.Run("leaves out names bound while evaluating", func(t *testing.T) {
+		asked = nil
+		n, err := parseRule("[heroPronouns:she][sidekick:#name#]#heroThey# and #sidekick# [if mood==:#greeting#]")
+		if !assert.Nil(t, err) {
+			return
+		}
+		e := NewEvaluation(&strings.Builder{}, WithGrammar(g), WithProvider(provider))
+		assert.Nil(t, e.Prefetch(n, 2))
+		assert.ElementsMatch(t, []string{"greeting", "name", "title"}, asked)
+	})
+	t.Run("matches evaluating without prefetch", func(t *testing.T) {
+		for seed := int64(0); seed < 10; seed++ {
+			var plain, fetched strings.Builder
+			NewEvaluation(&plain, WithGrammar(g), WithProvider(provider), WithRandom(NewSplitMix(seed))).Evaluate(origin)
+			NewEvaluation(&fetched, WithGrammar(g), WithProvider(provider), WithRandom(NewSplitMix(seed)), WithPrefetch(4)).Evaluate(origin)
+			assert.Equal(t, plain.String(), fetched.String())
+		}
+	})
+	t.Run("respects the context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		e := NewEvaluation(&strings.Builder{}, WithGrammar(g), WithProvider(provider), WithContext(ctx))
+		assert.Equal(t, context.Canceled, e.Prefetch(origin, 2))
+	})
+}
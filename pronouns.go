@@ -0,0 +1,174 @@
+package tracerygo
+
+import (
+	"io"
+	"strings"
+	"unicode"
+)
+
+// This is a set of pronouns a character can be referred to by
+type PronounSet struct {
+	They     string
+	Them     string
+	Their    string
+	Theirs   string
+	Themself string
+	// Whether verbs agree with it as they do with 'they', e.g. 'they are' rather than 'she is'
+	Plural bool
+}
+
+var pronounSets = map[string]PronounSet{
+	"they": {"they", "them", "their", "theirs", "themself", true},
+	"she":  {"she", "her", "her", "hers", "herself", false},
+	"he":   {"he", "him", "his", "his", "himself", false},
+	"it":   {"it", "it", "its", "its", "itself", false},
+}
+
+// This adds a pronoun set that can be bound by its name, e.g. '[pronouns:xe]', alongside the built-in 'they', 'she', 'he' and 'it'
+func WithPronouns(name string, set PronounSet) EvaluationModifier {
+	return func(e *Evaluation) {
+		sets := make(map[string]PronounSet, len(e.pronounSets)+1)
+		for k, v := range e.pronounSets {
+			sets[k] = v
+		}
+		sets[name] = set
+		e.pronounSets = sets
+	}
+}
+
+// this returns the prefix of the names a pronoun declaration binds: '[pronouns:she]' binds 'they', 'them' and so on, and '[heroPronouns:she]' binds 'heroThey', 'heroThem' and so on
+func pronounPrefix(key string) (string, bool) {
+	if key == "pronouns" {
+		return "", true
+	}
+	if strings.HasSuffix(key, "Pronouns") && len(key) > len("Pronouns") {
+		return key[:len(key)-len("Pronouns")], true
+	}
+	return "", false
+}
+
+// these are the names a pronoun declaration binds, before any prefix
+var pronounNames = []string{"they", "them", "their", "theirs", "themself", "theyAre", "theyWere", "theyHave"}
+
+// this is the name bound for a character, e.g. 'heroThey' for 'they' with the prefix 'hero'
+func pronounName(prefix string, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + strings.ToUpper(name[:1]) + name[1:]
+}
+
+// this binds the names for the pronoun set a declaration gives; the value is either the name of a set or a set written out, e.g. 'xe/xem/xyr/xyrs/xemself'
+func (e *Evaluation) bindPronouns(prefix string, key string, nodes []Node) error {
+	n := nodes[0]
+	if len(nodes) > 1 {
+		// the set has to stay the same for the whole scope, so a rule set is picked from once here
+		i, err := e.draw(key, e.path+"/["+key+"]", len(nodes))
+		if err != nil {
+			return err
+		}
+		n = nodes[i]
+	}
+	var sb strings.Builder
+	vare := *e
+	vare.out = &sb
+	if err := vare.Evaluate(n); err != nil {
+		return err
+	}
+	value := strings.TrimSpace(sb.String())
+
+	set, ok := e.pronounSets[value]
+	if !ok {
+		set, ok = pronounSets[value]
+	}
+	if forms := strings.Split(value, "/"); !ok && len(forms) == 5 {
+		set, ok = PronounSet{forms[0], forms[1], forms[2], forms[3], forms[4], false}, true
+	}
+	if !ok {
+		return ErrorUnknownPronouns{key, value}
+	}
+
+	bind := func(name string, value string) {
+		e.Grammar[pronounName(prefix, name)] = []Node{{Parts: []interface{}{value}}}
+	}
+	be, was, have := "is", "was", "has"
+	if set.Plural {
+		be, was, have = "are", "were", "have"
+	}
+	bind("they", set.They)
+	bind("them", set.Them)
+	bind("their", set.Their)
+	bind("theirs", set.Theirs)
+	bind("themself", set.Themself)
+	bind("theyAre", set.They+" "+be)
+	bind("theyWere", set.They+" "+was)
+	bind("theyHave", set.They+" "+have)
+	e.Grammar[key] = []Node{{Parts: []interface{}{value}}}
+
+	// the sets are shared with the scope this one is cloned from, so they're copied rather than changed
+	sets := make(map[string]PronounSet, len(e.pronouns)+1)
+	for k, v := range e.pronouns {
+		sets[k] = v
+	}
+	sets[prefix] = set
+	e.pronouns = sets
+	return nil
+}
+
+// this is the '.agree' modifier for a character in this evaluation; '.agree' agrees with the set bound by 'pronouns' and '.heroAgree' with the one bound by 'heroPronouns'.
+// Verbs are left alone if the character has no set bound
+func (e *Evaluation) agree(character string) ModifierFunc {
+	return func(out io.Writer) Modifier {
+		if set, ok := e.pronouns[character]; ok && !set.Plural {
+			return ModifierThirdPerson(out)
+		}
+		return &bufferedPipe{out: out, transform: func(s string) string { return s }}
+	}
+}
+
+// this returns the character a modifier like 'heroAgree' agrees with
+func agreementPrefix(modifier string) (string, bool) {
+	if strings.HasSuffix(modifier, "Agree") && len(modifier) > len("Agree") {
+		return modifier[:len(modifier)-len("Agree")], true
+	}
+	return "", false
+}
+
+// this is the character the modifier at i agrees with, if it names one
+func (s Substitution) character(i int) string {
+	if i < len(s.Agreement) {
+		return s.Agreement[i]
+	}
+	return ""
+}
+
+// This returns a modifier for putting a verb in the third person singular, e.g. 'run' into 'runs'; in a phrase, the first word is changed
+func ModifierThirdPerson(out io.Writer) Modifier {
+	return &bufferedPipe{out: out, transform: thirdPerson}
+}
+
+var irregularThirdPerson = map[string]string{
+	"be": "is", "are": "is", "were": "was", "have": "has", "do": "does", "go": "goes",
+}
+
+func thirdPerson(s string) string {
+	end := strings.IndexFunc(s, unicode.IsSpace)
+	if end < 0 {
+		end = len(s)
+	}
+	verb, rest := s[:end], s[end:]
+	if verb == "" {
+		return s
+	}
+	lower := strings.ToLower(verb)
+	if irregular, ok := irregularThirdPerson[lower]; ok {
+		return matchCapital(verb, irregular) + rest
+	}
+	switch {
+	case hasSuffix(lower, "s", "x", "z", "ch", "sh", "o"):
+		return verb + "es" + rest
+	case len(lower) > 1 && lower[len(lower)-1] == 'y' && !strings.ContainsRune("aeiou", rune(lower[len(lower)-2])):
+		return verb[:len(verb)-1] + "ies" + rest
+	}
+	return verb + "s" + rest
+}
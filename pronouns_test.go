@@ -0,0 +1,90 @@
+package tracerygo
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPronouns(t *testing.T) {
+	g, err := Parse(RawGrammar{
+		"story": []string{"#they.capitalize# #run.agree# home. #theyAre.capitalize# tired, so #they# #rest.agree# in #their# chair by #themself#."},
+		"run":   []string{"run"},
+		"rest":  []string{"rest"},
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+	evaluate := func(rule string, modifiers ...EvaluationModifier) (string, error) {
+		return evaluateRule(rule, append([]EvaluationModifier{WithGrammar(g)}, modifiers...)...)
+	}
+
+	t.Run("sets", func(t *testing.T) {
+		for set, expected := range map[string]string{
+			"she":                     "She runs home. She is tired, so she rests in her chair by herself.",
+			"they":                    "They run home. They are tired, so they rest in their chair by themself.",
+			"he":                      "He runs home. He is tired, so he rests in his chair by himself.",
+			"xe/xem/xyr/xyrs/xemself": "Xe runs home. Xe is tired, so xe rests in xyr chair by xemself.",
+		} {
+			result, err := evaluate("#[pronouns:" + set + "]story#")
+			assert.Nil(t, err, set)
+			assert.Equal(t, expected, result, set)
+		}
+		result, err := evaluate("[pronouns:ey]#story#", WithPronouns("ey", PronounSet{"ey", "em", "eir", "eirs", "emself", true}))
+		assert.Nil(t, err)
+		assert.Equal(t, "Ey run home. Ey are tired, so ey rest in eir chair by emself.", result)
+	})
+	t.Run("scoped like variables", func(t *testing.T) {
+		result, err := evaluate("[pronouns:she]#they# #run.agree#, #[pronouns:they]story# #they# #run.agree#")
+		assert.Nil(t, err)
+		assert.Equal(t, "she runs, They run home. They are tired, so they rest in their chair by themself. she runs", result)
+		_, err = evaluate("#[pronouns:she]run# #they#")
+		assert.Equal(t, ErrorNameNotFound{"they"}, err)
+	})
+	t.Run("several characters", func(t *testing.T) {
+		result, err := evaluate("[heroPronouns:she][villainPronouns:he]#heroThey# saw #villainThem#; #villainTheyWere# gone with #heroTheir# map. [if heroPronouns==she:yes]")
+		assert.Nil(t, err)
+		assert.Equal(t, "she saw him; he was gone with her map. yes", result)
+		result, err = evaluate("[heroPronouns:she][villainPronouns:they]#heroThey# #run.heroAgree#, #villainThey# #run.villainAgree#, #run.agree#")
+		assert.Nil(t, err)
+		assert.Equal(t, "she runs, they run, run", result)
+		result, err = evaluate("[pronouns:he][villainPronouns:they]#they# #run.agree#")
+		assert.Nil(t, err)
+		assert.Equal(t, "he runs", result)
+	})
+	t.Run("picked once from a rule set", func(t *testing.T) {
+		for seed := int64(0); seed < 10; seed++ {
+			result, err := evaluate("[pronouns:she,he]#they#|#they#|#theyHave#", WithStableRandom(seed))
+			assert.Nil(t, err)
+			parts := strings.Split(result, "|")
+			assert.Equal(t, parts[0], parts[1])
+			assert.Equal(t, parts[0]+" has", parts[2])
+		}
+	})
+	t.Run("unknown", func(t *testing.T) {
+		_, err := evaluate("[pronouns:ze]#they#")
+		assert.Equal(t, ErrorUnknownPronouns{"pronouns", "ze"}, err)
+	})
+	t.Run("no set bound", func(t *testing.T) {
+		result, err := evaluate("#run.agree#")
+		assert.Nil(t, err)
+		assert.Equal(t, "run", result)
+	})
+	t.Run("empty verbs", func(t *testing.T) {
+		for _, set := range []string{"she", "they"} {
+			result, err := evaluate("[pronouns:" + set + "][x:]#x.capitalize.agree##x.a.agree#.")
+			assert.Nil(t, err, set)
+			assert.Equal(t, ".", result, set)
+		}
+	})
+}
+
+func TestThirdPerson(t *testing.T) {
+	for verb, expected := range map[string]string{
+		"run": "runs", "watch": "watches", "go": "goes", "fly": "flies", "play": "plays", "fix": "fixes",
+		"be": "is", "have": "has", "Do": "Does", "walk away": "walks away", "": "",
+	} {
+		assert.Equal(t, expected, thirdPerson(verb), verb)
+	}
+}
@@ -0,0 +1,52 @@
+package tracerygo
+
+import (
+	"context"
+	"fmt"
+)
+
+// This is a richer source of names than a LookupFunction. It's given the evaluation's context, so it can respect deadlines,
+// and the rules it returns are tracery syntax that is parsed and expanded like the grammar. It should return ErrLookupNotFound for names it doesn't know
+type Provider interface {
+	Lookup(ctx context.Context, name string) ([]string, error)
+}
+
+// This adapts a function to the Provider interface
+type ProviderFunc func(ctx context.Context, name string) ([]string, error)
+
+// This calls the function
+func (f ProviderFunc) Lookup(ctx context.Context, name string) ([]string, error) {
+	return f(ctx, name)
+}
+
+// This provides a custom provider to an evaluation context; it's consulted for names missing from the grammar before any lookup function
+func WithProvider(p Provider) EvaluationModifier {
+	return func(e *Evaluation) {
+		e.provider = p
+	}
+}
+
+// This provides a context to an evaluation; the evaluation stops with the context's error once it's done, and it's passed to the provider
+func WithContext(ctx context.Context) EvaluationModifier {
+	return func(e *Evaluation) {
+		e.ctx = ctx
+	}
+}
+
+// this asks the provider for the rules of a name and parses them
+func (e *Evaluation) provide(name string) ([]Node, error) {
+	rules, err := e.provider.Lookup(e.ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if len(rules) == 0 {
+		return nil, ErrLookupNotFound
+	}
+	nodes := make([]Node, len(rules))
+	for i, raw := range rules {
+		if nodes[i], err = parseRule(raw); err != nil {
+			return nil, ErrorInField{fmt.Sprintf("%s[%d]", name, i), err}
+		}
+	}
+	return nodes, nil
+}
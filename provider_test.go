@@ -0,0 +1,63 @@
+package tracerygo
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProvider(t *testing.T) {
+	provider := ProviderFunc(func(ctx context.Context, name string) ([]string, error) {
+		switch name {
+		case "title":
+			return []string{"#adj# knight"}, nil
+		case "broken":
+			return []string{"#unclosed"}, nil
+		case "down":
+			return nil, errors.New("remote is down")
+		case "role":
+			return []string{"admin"}, nil
+		}
+		return nil, ErrLookupNotFound
+	})
+	grammar := WithGrammar(Grammar{"adj": {{Parts: []interface{}{"brave"}}}})
+	evaluate := func(rule string, modifiers ...EvaluationModifier) (string, error) {
+		return evaluateRule(rule, append([]EvaluationModifier{grammar, WithProvider(provider)}, modifiers...)...)
+	}
+
+	t.Run("expands rules", func(t *testing.T) {
+		result, err := evaluate("a #title.a#")
+		assert.Nil(t, err)
+		assert.Equal(t, "a a brave knight", result)
+	})
+	t.Run("falls through to lookup", func(t *testing.T) {
+		result, err := evaluate("#hero#", WithLookup(MapLookup(map[string]string{"hero": "Ada"})))
+		assert.Nil(t, err)
+		assert.Equal(t, "Ada", result)
+		_, err = evaluate("#hero#")
+		assert.Equal(t, ErrorNameNotFound{"hero"}, err)
+	})
+	t.Run("conditions", func(t *testing.T) {
+		for _, modifiers := range [][]EvaluationModifier{nil, {WithPrefetch(2)}} {
+			result, err := evaluate("[if role==admin:YES|NO][if nobody==:, unset|, set]", modifiers...)
+			assert.Nil(t, err)
+			assert.Equal(t, "YES, unset", result)
+		}
+		_, err := evaluate("[if down==up:a|b]")
+		assert.IsType(t, ErrorLookup{}, err)
+	})
+	t.Run("errors", func(t *testing.T) {
+		_, err := evaluate("#down#")
+		assert.IsType(t, ErrorLookup{}, err)
+		_, err = evaluate("#broken#")
+		assert.Equal(t, ErrorLookup{"broken", ErrorInField{"broken[0]", ErrorUnmatchedSymbol{0, "#", "#"}}}, err)
+	})
+	t.Run("respects the context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := evaluate("#title#", WithContext(ctx))
+		assert.Equal(t, context.Canceled, err)
+	})
+}
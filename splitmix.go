@@ -0,0 +1,58 @@
+package tracerygo
+
+import (
+	"math/bits"
+	"strings"
+)
+
+// This is a SplitMix64 random generator. Unlike math/rand, its output for a seed is part of this library's contract and won't change across Go releases or library versions
+type SplitMix struct {
+	state uint64
+}
+
+// This creates a SplitMix64 generator for a seed
+func NewSplitMix(seed int64) *SplitMix {
+	return &SplitMix{state: uint64(seed)}
+}
+
+// This returns the next raw 64 bits from the stream
+func (s *SplitMix) Uint64() uint64 {
+	s.state += 0x9e3779b97f4a7c15
+	z := s.state
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+// This returns a number in [0, n) without modulo bias; like math/rand it panics if n <= 0
+func (s *SplitMix) Intn(n int) int {
+	if n <= 0 {
+		panic("invalid argument to Intn")
+	}
+	bound := uint64(n)
+	hi, lo := bits.Mul64(s.Uint64(), bound)
+	if lo < bound {
+		threshold := -bound % bound
+		for lo < threshold {
+			hi, lo = bits.Mul64(s.Uint64(), bound)
+		}
+	}
+	return int(hi)
+}
+
+// This returns a number in [0.0, 1.0) using the top 53 bits of the stream
+func (s *SplitMix) Float64() float64 {
+	return float64(s.Uint64()>>11) / (1 << 53)
+}
+
+// This provides a SplitMix64 generator for the seed to an evaluation context
+func WithStableRandom(seed int64) EvaluationModifier {
+	return WithRandom(NewSplitMix(seed))
+}
+
+// This evaluates like Evaluate, but draws from SplitMix64 rather than math/rand so the output is stable across Go releases
+func (g Grammar) EvaluateStable(name string, index int, seed int64) (string, error) {
+	var sb strings.Builder
+	err := g.streamingEvaluate(&sb, name, index, WithStableRandom(seed))
+	return sb.String(), err
+}
@@ -0,0 +1,73 @@
+package tracerygo
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// these outputs are part of the library's contract; if any of them change, stored outputs generated with SplitMix change too
+func TestSplitMixGolden(t *testing.T) {
+	t.Run("raw stream", func(t *testing.T) {
+		// these match the reference SplitMix64 implementation for a zero seed
+		s := NewSplitMix(0)
+		assert.Equal(t, uint64(0xe220a8397b1dcdaf), s.Uint64())
+		assert.Equal(t, uint64(0x6e789e6aa1b965f4), s.Uint64())
+		assert.Equal(t, uint64(0x06c45d188009454f), s.Uint64())
+	})
+	t.Run("Intn", func(t *testing.T) {
+		s := NewSplitMix(42)
+		var values []int
+		for i := 0; i < 8; i++ {
+			values = append(values, s.Intn(100))
+		}
+		assert.Equal(t, []int{74, 15, 27, 34, 3, 86, 21, 80}, values)
+		assert.Panics(t, func() { s.Intn(0) })
+	})
+	t.Run("Float64", func(t *testing.T) {
+		s := NewSplitMix(7)
+		assert.Equal(t, 0.3898297483912715, s.Float64())
+		assert.Equal(t, 0.01678829452815611, s.Float64())
+	})
+	t.Run("grammar", func(t *testing.T) {
+		g, err := Parse(RawGrammar{
+			"origin":    []string{"hello #addressee#"},
+			"addressee": []string{"world", "planet", "there", "friend"},
+		})
+		if !assert.Nil(t, err) {
+			return
+		}
+		var results []string
+		for seed := int64(0); seed < 4; seed++ {
+			r, err := g.EvaluateStable("origin", 0, seed)
+			assert.Nil(t, err)
+			results = append(results, r)
+		}
+		assert.Equal(t, []string{"hello friend", "hello there", "hello there", "hello world"}, results)
+	})
+}
+
+// this is a random interface that plays back scripted values
+type scriptedRandom struct {
+	values []int
+}
+
+func (s *scriptedRandom) Intn(n int) int {
+	v := s.values[0] % n
+	s.values = s.values[1:]
+	return v
+}
+
+func (s *scriptedRandom) Float64() float64 {
+	return 0
+}
+
+func TestCustomRandomInterface(t *testing.T) {
+	var sb strings.Builder
+	e := NewEvaluation(&sb, WithRandom(&scriptedRandom{[]int{2, 1}}))
+	e.Grammar["a"] = []Node{{Parts: []interface{}{"x"}}, {Parts: []interface{}{"y"}}, {Parts: []interface{}{"z"}}}
+	err := e.Evaluate(Node{Parts: []interface{}{Substitution{Key: "a"}, Substitution{Key: "a"}}})
+	assert.Nil(t, err)
+	assert.Equal(t, "zy", sb.String())
+}
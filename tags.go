@@ -0,0 +1,75 @@
+package tracerygo
+
+import "strings"
+
+// This keeps rules with any of the given tags from being picked anywhere in the evaluation
+func WithTagFilter(exclude ...string) EvaluationModifier {
+	return func(e *Evaluation) {
+		e.excludedTags = append(append([]string(nil), e.excludedTags...), exclude...)
+	}
+}
+
+// this narrows the rules of a name to those the filter and the evaluation's excluded tags allow; if that leaves none, it's an error rather than an empty expansion
+func (e *Evaluation) filterTags(name string, nodes []Node, filter []string) ([]Node, error) {
+	if len(filter) == 0 && len(e.excludedTags) == 0 {
+		return nodes, nil
+	}
+	var allowed []Node
+	for _, n := range nodes {
+		if tagsAllow(n.Tags, filter) && !hasAnyTag(n.Tags, e.excludedTags) {
+			allowed = append(allowed, n)
+		}
+	}
+	if len(allowed) == 0 {
+		return nil, ErrorNoMatchingRules{name, filter, e.excludedTags}
+	}
+	return allowed, nil
+}
+
+// this is whether a rule's tags include every required tag in the filter and none of the excluded ones, which start with '!'
+func tagsAllow(tags []string, filter []string) bool {
+	for _, f := range filter {
+		if strings.HasPrefix(f, "!") {
+			if hasAnyTag(tags, []string{f[1:]}) {
+				return false
+			}
+		} else if !hasAnyTag(tags, []string{f}) {
+			return false
+		}
+	}
+	return true
+}
+
+func hasAnyTag(tags []string, any []string) bool {
+	for _, t := range tags {
+		for _, a := range any {
+			if t == a {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// this splits a list of tags like 'small, formal', dropping empty ones
+func splitTags(list string) []string {
+	var tags []string
+	for _, t := range strings.Split(list, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			tags = append(tags, t)
+		}
+	}
+	return tags
+}
+
+// this takes the tag filter off the end of a substitution's name (e.g. 'animal{small,!nsfw}')
+func nameTags(name string) (string, []string, error) {
+	open := strings.Index(name, "{")
+	if open < 0 {
+		return name, nil, nil
+	}
+	if !strings.HasSuffix(name, "}") {
+		return name, nil, ErrorUnmatchedSymbol{open, "{", "}"}
+	}
+	return name[:open], splitTags(name[open+1 : len(name)-1]), nil
+}
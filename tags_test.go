@@ -0,0 +1,88 @@
+package tracerygo
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTags(t *testing.T) {
+	var raw RawDocument
+	err := json.Unmarshal([]byte(`{
+		"animal": [
+			{"text": "mouse", "tags": ["small"]},
+			{"text": "elephant", "tags": ["large"]},
+			{"text": "wasp", "tags": ["small", "scary"]},
+			{"text": "bear", "tags": ["large", "scary"]},
+			{"text": "{braces}"},
+			"dog"
+		]
+	}`), &raw)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, []string{"mouse", "elephant", "wasp", "bear", "{braces}", "dog"}, raw.Grammar["animal"])
+	assert.Equal(t, [][]string{{"small"}, {"large"}, {"small", "scary"}, {"large", "scary"}, nil, nil}, raw.Tags["animal"])
+
+	g, err := ParseDocument(raw)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, Node{Parts: []interface{}{"wasp"}, Tags: []string{"small", "scary"}}, g["animal"][2])
+	assert.Equal(t, Node{Parts: []interface{}{"{braces}"}}, g["animal"][4])
+
+	// this collects every output the rule can have, by trying many seeds
+	outputs := func(rule string, modifiers ...EvaluationModifier) (map[string]bool, error) {
+		seen := make(map[string]bool)
+		for seed := int64(0); seed < 64; seed++ {
+			result, err := evaluateRule(rule, append([]EvaluationModifier{WithGrammar(g), WithStableRandom(seed)}, modifiers...)...)
+			if err != nil {
+				return nil, err
+			}
+			seen[result] = true
+		}
+		return seen, nil
+	}
+
+	t.Run("filter in substitutions", func(t *testing.T) {
+		seen, err := outputs("#animal{small}#")
+		assert.Nil(t, err)
+		assert.Equal(t, map[string]bool{"mouse": true, "wasp": true}, seen)
+		seen, err = outputs("#animal{small,!scary}.s#")
+		assert.Nil(t, err)
+		assert.Equal(t, map[string]bool{"mouses": true}, seen)
+	})
+	t.Run("evaluation wide exclusions", func(t *testing.T) {
+		seen, err := outputs("#animal#", WithTagFilter("scary", "large"))
+		assert.Nil(t, err)
+		assert.Equal(t, map[string]bool{"mouse": true, "{braces}": true, "dog": true}, seen)
+		seen, err = outputs("#animal{large}#", WithTagFilter("scary"))
+		assert.Nil(t, err)
+		assert.Equal(t, map[string]bool{"elephant": true}, seen)
+	})
+	t.Run("no candidates", func(t *testing.T) {
+		_, err := outputs("#animal{tiny}#")
+		assert.Equal(t, ErrorNoMatchingRules{"animal", []string{"tiny"}, nil}, err)
+		_, err = outputs("[pet:cat]#pet{small}#")
+		assert.Equal(t, ErrorNoMatchingRules{"pet", []string{"small"}, nil}, err)
+		_, err = outputs("#animal{large}#", WithTagFilter("large"))
+		assert.Equal(t, ErrorNoMatchingRules{"animal", []string{"large"}, []string{"large"}}, err)
+	})
+	t.Run("plain rules starting with a brace are unchanged", func(t *testing.T) {
+		g, err := Parse(RawGrammar{"origin": []string{"{curly} text", `{"json": 1}`, "{ unclosed"}})
+		if assert.Nil(t, err) {
+			assert.Equal(t, []Node{
+				{Parts: []interface{}{"{curly} text"}},
+				{Parts: []interface{}{`{"json": 1}`}},
+				{Parts: []interface{}{"{ unclosed"}},
+			}, g["origin"])
+		}
+	})
+	t.Run("malformed", func(t *testing.T) {
+		_, err := Parse(RawGrammar{"origin": []string{"#animal{small#"}})
+		assert.Equal(t, ErrorInField{"origin[0]", ErrorUnmatchedSymbol{6, "{", "}"}}, err)
+		err = json.Unmarshal([]byte(`{"animal": [{"tags": ["small"]}]}`), &raw)
+		assert.Equal(t, ErrorInField{"animal[0]", ErrorExpectationFailed{"an object with a string 'text'", "something else"}}, err)
+	})
+}
@@ -0,0 +1,93 @@
+package tracerygo
+
+import (
+	"strconv"
+	"strings"
+)
+
+// This is a compact record of every rule choice made during an evaluation, in the order they were made.
+// Names with a single rule aren't a choice, so they don't appear on the tape
+type Tape []int
+
+// This serializes the tape as dot separated rule indexes, e.g. '0.2.1'
+func (t Tape) String() string {
+	parts := make([]string, len(t))
+	for i, c := range t {
+		parts[i] = strconv.Itoa(c)
+	}
+	return strings.Join(parts, ".")
+}
+
+// This parses a tape serialized by String
+func ParseTape(s string) (Tape, error) {
+	if s == "" {
+		return Tape{}, nil
+	}
+	parts := strings.Split(s, ".")
+	t := make(Tape, len(parts))
+	for i, p := range parts {
+		c, err := strconv.Atoi(p)
+		if err != nil || c < 0 {
+			return nil, ErrorInField{strconv.Itoa(i), ErrorExpectationFailed{"a rule index", p}}
+		}
+		t[i] = c
+	}
+	return t, nil
+}
+
+// This allows tapes to be stored as text, e.g. alongside content in JSON
+func (t Tape) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+// This allows tapes to be read back from text
+func (t *Tape) UnmarshalText(b []byte) error {
+	parsed, err := ParseTape(string(b))
+	if err != nil {
+		return err
+	}
+	*t = parsed
+	return nil
+}
+
+// This records every rule choice the evaluation makes onto a tape
+func WithRecording(t *Tape) EvaluationModifier {
+	return func(e *Evaluation) {
+		e.tape = t
+	}
+}
+
+// This makes rule choices by playing back a tape instead of using the random interface. A tape with choices left over doesn't fit the grammar either; that's reported by Flush
+func WithReplay(t Tape) EvaluationModifier {
+	return func(e *Evaluation) {
+		position := 0
+		e.choose = func(name string, n int) (int, error) {
+			if position >= len(t) || t[position] >= n {
+				return 0, ErrorTapeMismatch{position, name}
+			}
+			position++
+			return t[position-1], nil
+		}
+		e.replayed = func() error {
+			if position < len(t) {
+				return ErrorTapeMismatch{position, ""}
+			}
+			return nil
+		}
+	}
+}
+
+// This evaluates like Evaluate, but also returns the tape of choices that were made
+func (g Grammar) EvaluateRecorded(name string, index int, seed int64) (string, Tape, error) {
+	var sb strings.Builder
+	t := Tape{}
+	err := g.streamingEvaluate(&sb, name, index, seedRandom(seed), WithRecording(&t))
+	return sb.String(), t, err
+}
+
+// This evaluates a field again by playing back a tape recorded by EvaluateRecorded; the result doesn't depend on the random interface at all
+func (g Grammar) Replay(name string, index int, t Tape) (string, error) {
+	var sb strings.Builder
+	err := g.streamingEvaluate(&sb, name, index, WithReplay(t))
+	return sb.String(), err
+}
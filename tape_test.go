@@ -0,0 +1,79 @@
+package tracerygo
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTape(t *testing.T) {
+	rawg := RawGrammar{
+		"origin": []string{"#[x:#a#]b# #a#"},
+		"a":      []string{"red", "blue", "green"},
+		"b":      []string{"#x# fox", "#x# owl"},
+	}
+	g, err := Parse(rawg)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	t.Run("record and replay", func(t *testing.T) {
+		for seed := int64(0); seed < 20; seed++ {
+			expected, _ := g.Evaluate("origin", 0, seed)
+			result, tape, err := g.EvaluateRecorded("origin", 0, seed)
+			assert.Nil(t, err)
+			assert.Equal(t, expected, result)
+			assert.Len(t, tape, 3)
+
+			replayed, err := g.Replay("origin", 0, tape)
+			assert.Nil(t, err)
+			assert.Equal(t, expected, replayed)
+		}
+	})
+	t.Run("replay survives new rules", func(t *testing.T) {
+		result, err := g.Replay("origin", 0, Tape{1, 2, 0})
+		assert.Nil(t, err)
+		assert.Equal(t, "green owl red", result)
+
+		edited := RawGrammar{
+			"origin": rawg["origin"],
+			"a":      append(rawg["a"], "pink"),
+			"b":      append(rawg["b"], "#x# cat"),
+		}
+		eg, err := Parse(edited)
+		if !assert.Nil(t, err) {
+			return
+		}
+		result, err = eg.Replay("origin", 0, Tape{1, 2, 0})
+		assert.Nil(t, err)
+		assert.Equal(t, "green owl red", result)
+	})
+	t.Run("mismatch", func(t *testing.T) {
+		_, err := g.Replay("origin", 0, Tape{2, 0, 0})
+		assert.Equal(t, ErrorTapeMismatch{0, "b"}, err)
+		_, err = g.Replay("origin", 0, Tape{1})
+		assert.Equal(t, ErrorTapeMismatch{1, "a"}, err)
+		_, err = g.Replay("origin", 0, Tape{1, 2, 0, 1})
+		assert.Equal(t, ErrorTapeMismatch{3, ""}, err)
+		assert.Equal(t, "tape has choices left over after choice 3", err.Error())
+
+		single, err := Parse(RawGrammar{"origin": []string{"only"}})
+		if assert.Nil(t, err) {
+			_, err = single.Replay("origin", 0, Tape{1, 1, 1, 1})
+			assert.Equal(t, ErrorTapeMismatch{0, ""}, err)
+		}
+	})
+	t.Run("serialization", func(t *testing.T) {
+		b, err := json.Marshal(struct{ Tape Tape }{Tape{0, 12, 3}})
+		assert.Nil(t, err)
+		assert.Equal(t, `{"Tape":"0.12.3"}`, string(b))
+
+		var record struct{ Tape Tape }
+		assert.Nil(t, json.Unmarshal(b, &record))
+		assert.Equal(t, Tape{0, 12, 3}, record.Tape)
+
+		_, err = ParseTape("1.x")
+		assert.Equal(t, ErrorInField{"1", ErrorExpectationFailed{"a rule index", "x"}}, err)
+	})
+}
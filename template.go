@@ -0,0 +1,56 @@
+package tracerygo
+
+import (
+	htmltemplate "html/template"
+	"strings"
+	"text/template"
+)
+
+// This makes template functions that evaluate the grammar: '{{ tracery "origin" .Seed }}' expands a name and '{{ flatten "#greeting#, #name#" .Seed }}' expands a rule.
+// The seed is optional; when it's left out, seed is called for one, or 0 is used if seed is nil. The modifiers are applied to every evaluation
+func FuncMap(g Grammar, seed func() int64, modifiers ...EvaluationModifier) template.FuncMap {
+	t := templateFuncs{g, seed, modifiers}
+	return template.FuncMap{
+		"tracery": t.tracery,
+		"flatten": t.flatten,
+	}
+}
+
+// This makes the same functions as FuncMap for html/template. They return plain text, so the template escapes generated text for wherever it's used (e.g. an element, an attribute or a script)
+func HTMLFuncMap(g Grammar, seed func() int64, modifiers ...EvaluationModifier) htmltemplate.FuncMap {
+	return htmltemplate.FuncMap(FuncMap(g, seed, modifiers...))
+}
+
+type templateFuncs struct {
+	grammar   Grammar
+	seed      func() int64
+	modifiers []EvaluationModifier
+}
+
+func (t templateFuncs) tracery(name string, seed ...int64) (string, error) {
+	return t.evaluate(Node{Parts: []interface{}{Substitution{Key: name}}}, seed)
+}
+
+func (t templateFuncs) flatten(rule string, seed ...int64) (string, error) {
+	n, err := parseRule(rule)
+	if err != nil {
+		return "", err
+	}
+	return t.evaluate(n, seed)
+}
+
+func (t templateFuncs) evaluate(n Node, seed []int64) (string, error) {
+	var s int64
+	if len(seed) > 0 {
+		s = seed[0]
+	} else if t.seed != nil {
+		s = t.seed()
+	}
+	var sb strings.Builder
+	e := NewEvaluation(&sb, append([]EvaluationModifier{WithGrammar(t.grammar), seedRandom(s)}, t.modifiers...)...)
+	err := e.Evaluate(n)
+	if err == nil {
+		err = e.Flush()
+	}
+	return sb.String(), err
+}
@@ -0,0 +1,62 @@
+package tracerygo
+
+import (
+	htmltemplate "html/template"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTemplates(t *testing.T) {
+	g, err := Parse(RawGrammar{
+		"origin":   []string{"#greeting#, #name#!"},
+		"greeting": []string{"hello", "hi", "hey"},
+		"name":     []string{"Tom & Jerry", "<b>Ada</b>"},
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+	next := int64(0)
+	seed := func() int64 {
+		next++
+		return next
+	}
+	data := struct{ Seed int64 }{3}
+
+	t.Run("text", func(t *testing.T) {
+		tmpl, err := template.New("t").Funcs(FuncMap(g, seed)).Parse(`{{ tracery "origin" .Seed }}|{{ flatten "#greeting.capitalize# there" 3 }}|{{ tracery "greeting" }}`)
+		if !assert.Nil(t, err) {
+			return
+		}
+		var sb strings.Builder
+		assert.Nil(t, tmpl.Execute(&sb, data))
+		assert.Equal(t, []string{
+			mustEvaluateRule(t, "#origin#", WithGrammar(g), seedRandom(3)),
+			mustEvaluateRule(t, "#greeting.capitalize# there", WithGrammar(g), seedRandom(3)),
+			mustEvaluateRule(t, "#greeting#", WithGrammar(g), seedRandom(1)),
+		}, strings.Split(sb.String(), "|"))
+	})
+	t.Run("html", func(t *testing.T) {
+		tmpl, err := htmltemplate.New("t").Funcs(HTMLFuncMap(g, nil)).Parse(`<p title="{{ tracery "name" 0 }}">{{ flatten "#name# and #name#" .Seed }}</p>`)
+		if !assert.Nil(t, err) {
+			return
+		}
+		var sb strings.Builder
+		assert.Nil(t, tmpl.Execute(&sb, data))
+		assert.NotContains(t, sb.String(), "<b>")
+		assert.NotContains(t, sb.String(), " & ")
+		assert.Equal(t, `<p title="`+htmltemplate.HTMLEscapeString(mustEvaluateRule(t, "#name#", WithGrammar(g), seedRandom(0)))+`">`+htmltemplate.HTMLEscapeString(mustEvaluateRule(t, "#name# and #name#", WithGrammar(g), seedRandom(3)))+`</p>`, sb.String())
+	})
+	t.Run("post processors", func(t *testing.T) {
+		tmpl := template.Must(template.New("t").Funcs(FuncMap(g, nil, WithPostProcessor(WordWrap(80)))).Parse(`{{ flatten "hello big wide world" }}`))
+		var sb strings.Builder
+		assert.Nil(t, tmpl.Execute(&sb, nil))
+		assert.Equal(t, "hello big wide world", sb.String())
+	})
+	t.Run("errors", func(t *testing.T) {
+		tmpl := template.Must(template.New("t").Funcs(FuncMap(g, nil)).Parse(`{{ tracery "missing" }}`))
+		assert.Error(t, tmpl.Execute(&strings.Builder{}, nil))
+	})
+}
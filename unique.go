@@ -0,0 +1,110 @@
+package tracerygo
+
+import (
+	"strings"
+)
+
+// This yields distinct outputs for a name, either by sampling successive seeds or by walking the grammar
+type UniqueGenerator struct {
+	grammar Grammar
+	name    string
+	seen    map[string]bool
+
+	// sampling state
+	seed      int64
+	maxMisses int
+
+	// enumeration state; nil when sampling
+	enumerator *Enumerator
+}
+
+// A unique modifier, when passed in to create the generator, changes how it searches for new outputs
+type UniqueModifier func(*UniqueGenerator)
+
+// This sets the first seed sampled; each subsequent sample uses the next seed up
+func UniqueFromSeed(seed int64) UniqueModifier {
+	return func(u *UniqueGenerator) {
+		u.seed = seed
+	}
+}
+
+// This sets how many repeated outputs in a row are tolerated while sampling before the grammar is considered exhausted
+func UniqueMaxMisses(misses int) UniqueModifier {
+	return func(u *UniqueGenerator) {
+		u.maxMisses = misses
+	}
+}
+
+// This walks every rule choice in order instead of sampling; exhaustion is then exact rather than a guess.
+// The depth is the most choices a single output can take, which protects against recursive grammars; 0 is unlimited
+func UniqueByEnumeration(maxDepth int) UniqueModifier {
+	return func(u *UniqueGenerator) {
+		u.enumerator = u.grammar.Enumerate(u.name, 0, maxDepth)
+	}
+}
+
+// This creates a generator of distinct outputs for a name, picking any of its rules
+func (g Grammar) Unique(name string, modifiers ...UniqueModifier) *UniqueGenerator {
+	u := &UniqueGenerator{
+		grammar:   g,
+		name:      name,
+		seen:      make(map[string]bool),
+		maxMisses: 100,
+	}
+	for _, m := range modifiers {
+		m(u)
+	}
+	return u
+}
+
+// This returns the next output that hasn't been returned before, or ErrorExhausted when no more can be found
+func (u *UniqueGenerator) Next() (string, error) {
+	if u.enumerator != nil {
+		return u.nextEnumerated()
+	}
+	for misses := 0; misses < u.maxMisses; misses++ {
+		var sb strings.Builder
+		e := NewEvaluation(&sb, seedRandom(u.seed), WithGrammar(u.grammar))
+		u.seed++
+		if err := e.Evaluate(symbolNode(u.name)); err != nil {
+			return "", err
+		}
+		if err := e.Flush(); err != nil {
+			return "", err
+		}
+		if s := sb.String(); !u.seen[s] {
+			u.seen[s] = true
+			return s, nil
+		}
+	}
+	return "", ErrorExhausted{u.name, len(u.seen)}
+}
+
+func (u *UniqueGenerator) nextEnumerated() (string, error) {
+	for u.enumerator.Next() {
+		if s := u.enumerator.Value(); !u.seen[s] {
+			u.seen[s] = true
+			return s, nil
+		}
+	}
+	if err := u.enumerator.Err(); err != nil {
+		return "", err
+	}
+	if u.enumerator.Truncated() {
+		return "", ErrorTruncated{u.name, len(u.seen)}
+	}
+	return "", ErrorExhausted{u.name, len(u.seen)}
+}
+
+// This collects the next count distinct outputs; if the grammar runs out first it returns what it found alongside ErrorExhausted
+func (u *UniqueGenerator) Take(count int) ([]string, error) {
+	results := make([]string, 0, count)
+	for len(results) < count {
+		s, err := u.Next()
+		if err != nil {
+			return results, err
+		}
+		results = append(results, s)
+	}
+	return results, nil
+}
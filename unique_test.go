@@ -0,0 +1,58 @@
+package tracerygo
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnique(t *testing.T) {
+	g, err := Parse(RawGrammar{
+		"origin": []string{"#a# #b#", "[c:#a#]#c# #c#"},
+		"a":      []string{"red", "blue"},
+		"b":      []string{"fox", "owl"},
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+	all := []string{"blue blue", "blue fox", "blue owl", "red fox", "red owl", "red red"}
+
+	t.Run("sampling", func(t *testing.T) {
+		results, err := g.Unique("origin", UniqueFromSeed(4)).Take(6)
+		assert.Nil(t, err)
+		sort.Strings(results)
+		assert.Equal(t, all, results)
+	})
+	t.Run("sampling exhausted", func(t *testing.T) {
+		results, err := g.Unique("origin", UniqueMaxMisses(50)).Take(10)
+		assert.Len(t, results, 6)
+		assert.Equal(t, ErrorExhausted{"origin", 6}, err)
+	})
+	t.Run("enumeration", func(t *testing.T) {
+		results, err := g.Unique("origin", UniqueByEnumeration(0)).Take(6)
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"red fox", "red owl", "blue fox", "blue owl", "red red", "blue blue"}, results)
+		_, err = g.Unique("origin", UniqueByEnumeration(0)).Take(7)
+		assert.Equal(t, ErrorExhausted{"origin", 6}, err)
+		_, err = g.Unique("origin", UniqueByEnumeration(5)).Take(7)
+		assert.Equal(t, ErrorExhausted{"origin", 6}, err)
+		_, err = g.Unique("origin", UniqueByEnumeration(1)).Take(7)
+		assert.IsType(t, ErrorTruncated{}, err)
+	})
+	t.Run("enumeration of a recursive grammar", func(t *testing.T) {
+		g, err := Parse(RawGrammar{
+			"origin": []string{"#origin#!", "hi"},
+		})
+		if !assert.Nil(t, err) {
+			return
+		}
+		results, err := g.Unique("origin", UniqueByEnumeration(4)).Take(5)
+		assert.Equal(t, []string{"hi!!!", "hi!!", "hi!", "hi"}, results)
+		assert.Equal(t, ErrorTruncated{"origin", 4}, err)
+	})
+	t.Run("errors are passed through", func(t *testing.T) {
+		_, err := g.Unique("missing").Next()
+		assert.Equal(t, ErrorNameNotFound{"missing"}, err)
+	})
+}
@@ -0,0 +1,74 @@
+package tracerygo
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// A predicate decides whether an output is acceptable to GenerateWhere
+type Predicate func(output string) bool
+
+// This accepts outputs of at most n characters, counted as runes rather than bytes
+func MaxLength(n int) Predicate {
+	return func(output string) bool {
+		return utf8.RuneCountInString(output) <= n
+	}
+}
+
+// This accepts outputs that match the regular expression somewhere; anchor it to match the whole output
+func Matching(re *regexp.Regexp) Predicate {
+	return re.MatchString
+}
+
+// This rejects outputs that contain any of the words, ignoring case; only whole words count, so blocking 'ass' doesn't reject 'class'
+func WithoutWords(words ...string) Predicate {
+	blocked := make(map[string]bool, len(words))
+	for _, w := range words {
+		blocked[strings.ToLower(w)] = true
+	}
+	return func(output string) bool {
+		for _, w := range strings.FieldsFunc(output, notWordRune) {
+			if blocked[strings.ToLower(w)] {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+func notWordRune(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\'' && r != '-'
+}
+
+// This accepts outputs that every one of the predicates accepts
+func AllOf(predicates ...Predicate) Predicate {
+	return func(output string) bool {
+		for _, p := range predicates {
+			if !p(output) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// This evaluates a rule of a name with StreamingEvaluate until an output satisfies the predicate; it returns the output and how many attempts it took.
+// The first attempt uses the seed itself, so it matches Evaluate with that seed, and the seeds for each retry are drawn from a SplitMix64 stream for the seed; the same seed always makes the same attempts.
+// If no attempt within maxAttempts is accepted it returns ErrorUnsatisfied, and an error evaluating an attempt is returned as ErrorInSeed
+func (g Grammar) GenerateWhere(name string, index int, seed int64, predicate Predicate, maxAttempts int) (string, int, error) {
+	seeds := NewSplitMix(seed)
+	attemptSeed := seed
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		var sb strings.Builder
+		if err := g.StreamingEvaluate(&sb, name, index, attemptSeed); err != nil {
+			return "", attempt, ErrorInSeed{attemptSeed, err}
+		}
+		if output := sb.String(); predicate(output) {
+			return output, attempt, nil
+		}
+		attemptSeed = int64(seeds.Uint64())
+	}
+	return "", maxAttempts, ErrorUnsatisfied{name, maxAttempts}
+}
@@ -0,0 +1,67 @@
+package tracerygo
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateWhere(t *testing.T) {
+	g, err := Parse(RawGrammar{
+		"origin": []string{"the #size# #animal#"},
+		"size":   []string{"tiny", "enormous", "middling"},
+		"animal": []string{"cat", "hippopotamus", "dog"},
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	t.Run("first attempt uses the seed", func(t *testing.T) {
+		for seed := int64(0); seed < 10; seed++ {
+			expected, err := g.Evaluate("origin", 0, seed)
+			assert.Nil(t, err)
+			output, attempts, err := g.GenerateWhere("origin", 0, seed, func(string) bool { return true }, 10)
+			assert.Nil(t, err)
+			assert.Equal(t, 1, attempts)
+			assert.Equal(t, expected, output)
+		}
+	})
+	t.Run("retries until accepted", func(t *testing.T) {
+		for seed := int64(0); seed < 20; seed++ {
+			predicate := AllOf(MaxLength(12), WithoutWords("DOG"))
+			output, attempts, err := g.GenerateWhere("origin", 0, seed, predicate, 100)
+			if assert.Nil(t, err) {
+				assert.Equal(t, "the tiny cat", output)
+				assert.True(t, attempts >= 1)
+				again, againAttempts, _ := g.GenerateWhere("origin", 0, seed, predicate, 100)
+				assert.Equal(t, output, again)
+				assert.Equal(t, attempts, againAttempts)
+			}
+		}
+	})
+	t.Run("unsatisfied", func(t *testing.T) {
+		_, attempts, err := g.GenerateWhere("origin", 0, 0, Matching(regexp.MustCompile("^a ")), 5)
+		assert.Equal(t, 5, attempts)
+		assert.Equal(t, ErrorUnsatisfied{"origin", 5}, err)
+	})
+	t.Run("evaluation error", func(t *testing.T) {
+		_, attempts, err := g.GenerateWhere("missing", 0, 3, MaxLength(10), 5)
+		assert.Equal(t, 1, attempts)
+		assert.IsType(t, ErrorInSeed{}, err)
+		_, _, err = g.GenerateWhere("origin", 1, 3, MaxLength(10), 5)
+		assert.IsType(t, ErrorInSeed{}, err)
+	})
+}
+
+func TestPredicates(t *testing.T) {
+	assert := assert.New(t)
+	assert.True(MaxLength(5)("héllo"))
+	assert.False(MaxLength(4)("héllo"))
+	assert.True(WithoutWords("ass")("a classy assortment"))
+	assert.False(WithoutWords("ass")("what an Ass!"))
+	assert.False(WithoutWords("don't")("Don't go"))
+	assert.True(Matching(regexp.MustCompile(`^\w+$`))("word"))
+	assert.False(AllOf(MaxLength(10), Matching(regexp.MustCompile("x")))("abc"))
+	assert.True(AllOf()("anything"))
+}